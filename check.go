@@ -0,0 +1,458 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// noGoExitCode is the exit status of --check when a station is below the
+// personal minimums.
+const noGoExitCode = 4
+
+// defaultCheckHours is how far ahead --check holds the TAF to the minimums.
+const defaultCheckHours = 3
+
+// minimums are the personal limits of the [minimums] config section. Zero
+// limits are not checked.
+type minimums struct {
+	CeilingFt        int     // lowest acceptable ceiling, ft AGL
+	VisibilitySM     float64 // lowest acceptable visibility
+	WindKt           float64 // strongest acceptable sustained wind
+	GustKt           float64 // strongest acceptable gust
+	CrosswindKt      float64 // strongest acceptable crosswind on the best runway, gusts included
+	NoThunderstorms  bool    // any TS group, including in the vicinity
+	NoFreezingPrecip bool    // FZRA or FZDZ
+	Tempo            bool    // also hold TEMPO, PROB and changing BECMG conditions to the limits
+}
+
+// minimumsFromConfig reads the [minimums] section of the config file at path.
+func minimumsFromConfig(c config, path string) (minimums, error) {
+	sec, ok := c["minimums"]
+	if !ok {
+		return minimums{}, fmt.Errorf("no [minimums] section in %s", path)
+	}
+	keys := make([]string, 0, len(sec))
+	for k := range sec {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	m := minimums{Tempo: true}
+	limit := func(s string) (float64, error) {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 {
+			return 0, fmt.Errorf("expected a positive number, got %q", s)
+		}
+		return v, nil
+	}
+	for _, k := range keys {
+		v := sec[k]
+		var err error
+		var b bool
+		switch k {
+		case "ceiling":
+			var ft float64
+			ft, err = limit(v)
+			m.CeilingFt = int(ft)
+		case "visibility":
+			m.VisibilitySM, err = limit(v)
+		case "wind":
+			m.WindKt, err = limit(v)
+		case "gust":
+			m.GustKt, err = limit(v)
+		case "crosswind":
+			m.CrosswindKt, err = limit(v)
+		case "thunderstorms":
+			b, err = parseConfigBool(v)
+			m.NoThunderstorms = !b
+		case "freezing_precip":
+			b, err = parseConfigBool(v)
+			m.NoFreezingPrecip = !b
+		case "tempo":
+			m.Tempo, err = parseConfigBool(v)
+		default:
+			return m, fmt.Errorf("%s: unknown key %q in [minimums]", path, k)
+		}
+		if err != nil {
+			return m, fmt.Errorf("%s: [minimums] %s: %w", path, k, err)
+		}
+	}
+	if m == (minimums{Tempo: m.Tempo}) {
+		return m, fmt.Errorf("%s: [minimums] sets no limits", path)
+	}
+	return m, nil
+}
+
+// checkResult is one criterion of a go/no-go check.
+type checkResult struct {
+	Name string // Ceiling, Visibility, ...
+	OK   bool
+	Text string // the value and, for a failure, the limit it breaks
+}
+
+// checkConditions holds the conditions of a report, or of an hour of a
+// forecast, to the minimums m. station, info and at locate the conditions
+// for the runway crosswind.
+func checkConditions(c Conditions, station string, info *stationInfo, at time.Time, m minimums) []checkResult {
+	var out []checkResult
+	add := func(name string, ok bool, format string, args ...any) {
+		out = append(out, checkResult{Name: name, OK: ok, Text: fmt.Sprintf(format, args...)})
+	}
+
+	if m.CeilingFt > 0 {
+		ceiling := c.Ceiling()
+		switch {
+		case !c.CAVOK && len(c.Sky) == 0:
+			add("Ceiling", false, "not reported")
+		case c.CeilingNotReported():
+			add("Ceiling", false, "not reported, broken, overcast or obscured at an unknown height")
+		case ceiling == nil:
+			add("Ceiling", true, "none (minimum %d ft)", m.CeilingFt)
+		case *ceiling < m.CeilingFt:
+			add("Ceiling", false, "%d ft, below the %d ft minimum", *ceiling, m.CeilingFt)
+		default:
+			add("Ceiling", true, "%d ft (minimum %d ft)", *ceiling, m.CeilingFt)
+		}
+	}
+
+	if m.VisibilitySM > 0 {
+		limit := formatFraction(m.VisibilitySM) + " SM"
+		switch v := c.Visibility; {
+		case c.CAVOK:
+			add("Visibility", true, "CAVOK, 10 km or more (minimum %s)", limit)
+		case v == nil:
+			add("Visibility", false, "not reported")
+		case v.StatuteMiles() < m.VisibilitySM || (v.Modifier == "M" && v.StatuteMiles() <= m.VisibilitySM):
+			add("Visibility", false, "%s, below the %s minimum", describeVisibility(v, displayUnits{}), limit)
+		default:
+			add("Visibility", true, "%s (minimum %s)", describeVisibility(v, displayUnits{}), limit)
+		}
+	}
+
+	if m.WindKt > 0 || m.GustKt > 0 || m.CrosswindKt > 0 {
+		out = append(out, checkWind(c.Wind, station, info, at, m)...)
+	}
+
+	if m.NoThunderstorms {
+		if g, ok := findWeather(c.Weather, func(g WeatherGroup) bool { return g.Descriptor == "TS" }); ok {
+			add("Thunderstorms", false, "%s (%s)", decodeWxToken(g.Raw), g.Raw)
+		} else {
+			add("Thunderstorms", true, "none")
+		}
+	}
+	if m.NoFreezingPrecip {
+		freezing := func(g WeatherGroup) bool {
+			return g.Descriptor == "FZ" && (containsPhenomenon(g, "RA") || containsPhenomenon(g, "DZ"))
+		}
+		if g, ok := findWeather(c.Weather, freezing); ok {
+			add("Freezing precipitation", false, "%s (%s)", decodeWxToken(g.Raw), g.Raw)
+		} else {
+			add("Freezing precipitation", true, "none")
+		}
+	}
+	return out
+}
+
+// checkWind holds the wind, its gusts and the crosswind on the best runway
+// to the minimums. Without runway data, with a variable direction or when
+// the magnetic variation is unknown, the whole wind is taken as crosswind.
+func checkWind(w *Wind, station string, info *stationInfo, at time.Time, m minimums) []checkResult {
+	var out []checkResult
+	add := func(name string, ok bool, format string, args ...any) {
+		out = append(out, checkResult{Name: name, OK: ok, Text: fmt.Sprintf(format, args...)})
+	}
+	if w == nil {
+		add("Wind", false, "not reported")
+		return out
+	}
+	toKt := metersPerSecond[w.Unit] / metersPerSecond["KT"]
+	speed := float64(w.Speed) * toKt
+	strongest := speed
+	if w.Gust != nil {
+		strongest = math.Max(speed, float64(*w.Gust)*toKt)
+	}
+	over := func(v, limit float64) bool { return math.Round(v) > limit }
+	kt := func(v float64) string { return knots(v, displayUnits{}) }
+
+	if m.WindKt > 0 {
+		if over(speed, m.WindKt) {
+			add("Wind", false, "%s, above the %s maximum", kt(speed), kt(m.WindKt))
+		} else {
+			add("Wind", true, "%s (maximum %s)", kt(speed), kt(m.WindKt))
+		}
+	}
+	if m.GustKt > 0 {
+		switch {
+		case w.Gust == nil:
+			add("Gusts", true, "none (maximum %s)", kt(m.GustKt))
+		case over(float64(*w.Gust)*toKt, m.GustKt):
+			add("Gusts", false, "%s, above the %s maximum", kt(float64(*w.Gust)*toKt), kt(m.GustKt))
+		default:
+			add("Gusts", true, "%s (maximum %s)", kt(float64(*w.Gust)*toKt), kt(m.GustKt))
+		}
+	}
+	if m.CrosswindKt <= 0 {
+		return out
+	}
+
+	limit := kt(m.CrosswindKt)
+	if w.Calm() {
+		add("Crosswind", true, "calm (maximum %s)", limit)
+		return out
+	}
+	o := &Observation{Station: station, Conditions: Conditions{Wind: w}, Info: info, ObservedAt: at}
+	rws := runwayWinds(o, m.CrosswindKt)
+	if len(rws) == 0 {
+		why := "no runway data for " + station
+		switch {
+		case w.Variable:
+			why = "variable wind"
+		case len(runwayTable()[station]) > 0:
+			why = "magnetic variation at " + station + " unknown"
+		}
+		if over(strongest, m.CrosswindKt) {
+			add("Crosswind", false, "up to %s (%s; the whole wind is taken as crosswind), above the %s maximum", kt(strongest), why, limit)
+		} else {
+			add("Crosswind", true, "up to %s (%s; the whole wind is taken as crosswind; maximum %s)", kt(strongest), why, limit)
+		}
+		return out
+	}
+	for _, rw := range rws {
+		if !rw.Favored {
+			continue
+		}
+		cross := math.Abs(rw.Crosswind)
+		if rw.GustCrosswind != nil {
+			cross = math.Max(cross, math.Abs(*rw.GustCrosswind))
+		}
+		if rw.ExceedsLimit {
+			add("Crosswind", false, "%s on runway %s, the best runway, above the %s maximum", kt(cross), rw.Runway, limit)
+		} else {
+			add("Crosswind", true, "%s on runway %s (maximum %s)", kt(cross), rw.Runway, limit)
+		}
+		break
+	}
+	return out
+}
+
+func findWeather(wx []WeatherGroup, match func(WeatherGroup) bool) (WeatherGroup, bool) {
+	for _, g := range wx {
+		if match(g) {
+			return g, true
+		}
+	}
+	return WeatherGroup{}, false
+}
+
+func containsPhenomenon(g WeatherGroup, p string) bool {
+	for _, ph := range g.Phenomena {
+		if ph == p {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTAF holds every hour of t between from and to to the minimums,
+// including the TEMPO, PROB and BECMG conditions when m.Tempo is set. It
+// returns the first failure of each criterion, and false when the TAF does
+// not cover any of the period.
+func checkTAF(t *TAF, info *stationInfo, from, to time.Time, m minimums) ([]checkResult, bool) {
+	var failures []checkResult
+	failed := map[string]bool{}
+	note := func(results []checkResult, when, source string) {
+		for _, r := range results {
+			if r.OK || failed[r.Name] {
+				continue
+			}
+			failed[r.Name] = true
+			r.Text += " (" + when
+			if source != "" {
+				r.Text += ", " + source
+			}
+			r.Text += ")"
+			failures = append(failures, r)
+		}
+	}
+
+	covered := false
+	for _, h := range t.Timeline(from) {
+		if !h.Start.Add(time.Hour).After(from) || !h.Start.Before(to) {
+			continue
+		}
+		covered = true
+		when := h.Start.Format("02/1504Z")
+		note(checkConditions(h.Prevailing, t.Station, info, h.Start, m), when, "")
+		if m.Tempo {
+			for _, p := range h.Possible {
+				note(checkConditions(p.Conditions, t.Station, info, h.Start, m), when, p.Source)
+			}
+		}
+	}
+	return failures, covered
+}
+
+// stationCheck is the go/no-go check of one station.
+type stationCheck struct {
+	Station string
+	METAR   *Observation
+	Stale   bool
+	Results []checkResult // for the METAR
+	TAF     *TAF
+	TAFErr  error         // why TAF is nil: the lookup or parse failed
+	Covered bool          // the TAF covers part of the period
+	TAFFail []checkResult // the first TAF failure of each criterion
+}
+
+// goNoGo reports whether every criterion passed. A station without a TAF
+// that covers the period is a no-go.
+func (sc stationCheck) goNoGo() bool {
+	if sc.METAR == nil || sc.Stale || !sc.Covered || len(sc.TAFFail) > 0 {
+		return false
+	}
+	for _, r := range sc.Results {
+		if !r.OK {
+			return false
+		}
+	}
+	return true
+}
+
+// runCheck fetches the latest METAR and the TAF of each station, holds them
+// to the minimums for the next hours and prints a go/no-go report. It
+// returns true when every station is a go.
+func runCheck(stations []string, m minimums, hours int, do decodeOptions) (bool, error) {
+	q := url.Values{}
+	q.Set("ids", strings.Join(stations, ","))
+	q.Set("taf", "false")
+	body, err := fetchAWProduct("metar", q, do.timeout, do.userAgent, true)
+	if err != nil {
+		return false, err
+	}
+	var arr []awMetar
+	if err := json.Unmarshal(body, &arr); err != nil {
+		return false, fmt.Errorf("decode JSON: %w (first 200 bytes: %q)", err, preview(body, 200))
+	}
+	groups, missing := orderByStation(arr, awStation, stations)
+	warnMissing(do.stale, missing)
+	var latest []awMetar
+	for _, g := range groups {
+		sortChronological(g, awObsTime)
+		latest = append(latest, g[len(g)-1])
+	}
+	stale := flagStale(do.stale, latest, awStation, awObsTime)
+
+	// Each TAF is parsed on its own, so one that fails only fails the check
+	// of its station.
+	tafs := map[string]*TAF{}
+	tafErrs := map[string]error{}
+	tq := url.Values{}
+	tq.Set("ids", strings.Join(stations, ","))
+	if body, err := fetchAWProduct("taf", tq, do.timeout, do.userAgent, false); err != nil {
+		for _, station := range stations {
+			tafErrs[station] = fmt.Errorf("lookup failed: %w", err)
+		}
+	} else {
+		for _, r := range splitTAFs(string(body)) {
+			if r == "TAF" {
+				continue
+			}
+			t, err := ParseTAF(r)
+			if err != nil {
+				tafErrs[tafStation(r)] = err
+				continue
+			}
+			tafs[t.Station] = t
+		}
+	}
+
+	now := time.Now()
+	until := now.Add(time.Duration(hours) * time.Hour)
+	allGo := true
+	for i, station := range stations {
+		sc := stationCheck{Station: station, TAF: tafs[station], TAFErr: tafErrs[station]}
+		for j, mt := range latest {
+			if strings.EqualFold(awStation(mt), station) {
+				sc.METAR = observationFromAW(mt)
+				sc.Stale = stale[j]
+				sc.Results = checkConditions(sc.METAR.Conditions, station, sc.METAR.Info, sc.METAR.ObservedAt, m)
+			}
+		}
+		if sc.TAF != nil {
+			var info *stationInfo
+			if sc.METAR != nil {
+				info = sc.METAR.Info
+			}
+			sc.TAFFail, sc.Covered = checkTAF(sc.TAF, info, now, until, m)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		printStationCheck(sc, now, until)
+		allGo = allGo && sc.goNoGo()
+	}
+	return allGo, nil
+}
+
+// printStationCheck prints the go/no-go report of one station.
+func printStationCheck(sc stationCheck, now, until time.Time) {
+	verdict := "GO"
+	if !sc.goNoGo() {
+		verdict = "NO-GO"
+	}
+	fmt.Printf("%s: %s\n", sc.Station, verdict)
+	line := func(ok bool, name, text string) {
+		status := "OK"
+		if !ok {
+			status = "FAIL"
+		}
+		fmt.Printf("    %-4s  %s: %s\n", status, name, text)
+	}
+
+	if sc.METAR == nil {
+		fmt.Println("  METAR: none returned")
+		line(false, "METAR", "no current observation")
+	} else {
+		o := sc.METAR
+		fmt.Printf("  METAR %s, %s\n", o.Time, describeAge(now.Sub(o.ObservedAt)))
+		if sc.Stale {
+			line(false, "Report age", "no newer report; the station may have stopped reporting")
+		}
+		for _, r := range sc.Results {
+			line(r.OK, r.Name, r.Text)
+		}
+	}
+
+	period := fmt.Sprintf("%s to %s", now.UTC().Format("02/1504Z"), until.UTC().Format("02/1504Z"))
+	switch {
+	case sc.TAF == nil && sc.TAFErr != nil:
+		fmt.Println("  TAF: not usable")
+		line(false, "TAF", sc.TAFErr.Error())
+	case sc.TAF == nil:
+		fmt.Println("  TAF: none returned")
+		line(false, "TAF", "no forecast for "+period)
+	case sc.TAF.Cancelled:
+		fmt.Printf("  TAF %s\n", sc.TAF.IssueTime)
+		line(false, "TAF", "cancelled")
+	case sc.TAF.NoForecast:
+		fmt.Printf("  TAF %s\n", sc.TAF.IssueTime)
+		line(false, "TAF", "no forecast issued (NIL)")
+	case !sc.Covered:
+		fmt.Printf("  TAF %s\n", sc.TAF.IssueTime)
+		line(false, "TAF", "does not cover "+period)
+	default:
+		fmt.Printf("  TAF %s, %s\n", sc.TAF.IssueTime, period)
+		if len(sc.TAFFail) == 0 {
+			fmt.Println("    OK    All minimums met")
+		}
+		for _, r := range sc.TAFFail {
+			line(false, r.Name, r.Text)
+		}
+	}
+}
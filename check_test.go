@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestCheckConditionsCeiling(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{"KTYS 151753Z 21010KT 10SM BKN020 20/10 A3000", true},
+		{"KTYS 151753Z 21010KT 10SM BKN008 20/10 A3000", false},
+		{"KTYS 151753Z 21010KT 10SM SCT008 20/10 A3000", true},
+		{"KTYS 151753Z 21010KT 10SM BKN/// 20/10 A3000", false},
+		{"KTYS 151753Z 21010KT 1/4SM FG VV/// 10/10 A3000", false},
+	}
+	for _, tt := range tests {
+		o, err := ParseMETAR(tt.raw)
+		if err != nil {
+			t.Fatalf("ParseMETAR(%q): %v", tt.raw, err)
+		}
+		res := checkConditions(o.Conditions, o.Station, nil, o.ObservedAt, minimums{CeilingFt: 1000})
+		if len(res) != 1 || res[0].OK != tt.want {
+			t.Errorf("%s: ceiling check %+v, want OK %v", tt.raw, res, tt.want)
+		}
+	}
+}
+
+func TestCheckWindWithoutVariation(t *testing.T) {
+	// 250° is 20° off runway 23 at KTYS, but without a position the variation
+	// is unknown and the whole 15 kt counts as crosswind.
+	w := &Wind{Direction: 250, Speed: 15, Unit: "KT"}
+	res := checkWind(w, "KTYS", nil, utc(2026, 10, 15, 18, 0), minimums{CrosswindKt: 10})
+	if len(res) != 1 || res[0].OK {
+		t.Errorf("crosswind check %+v, want a failure", res)
+	}
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// command is a subcommand: "metar-tool obs KTYS". Each one takes a subset of
+// the option flags and maps its arguments onto the options the flag forms
+// set, so "metar-tool obs KTYS --decode" runs as "metar-tool --obs KTYS
+// --decode" does.
+type command struct {
+	name     string
+	args     string // argument synopsis for the help
+	summary  string
+	flags    []string // option flags, besides the commonFlags
+	examples []string
+	setup    func(opt *options, args []string) ([]string, error) // returns the arguments left for run
+}
+
+// commonFlags are taken by every command that runs.
+var commonFlags = []string{"timeout", "user-agent", "output", "verbose", "config"}
+
+// decodedFlags select how decoded observations look.
+var decodedFlags = []string{"format", "pretty", "units", "derived", "runways", "crosswind-limit", "stale-after", "fail-stale"}
+
+var commands = []command{
+	{
+		name:    "obs",
+		args:    "[STATION|@group]...",
+		summary: "Latest METARs for stations, a group, the nearest stations or an area",
+		flags: append([]string{"stations-file", "hours", "near", "count", "radius", "center", "bbox", "json", "decode"},
+			decodedFlags...),
+		examples: []string{
+			"metar-tool obs KTYS",
+			"metar-tool obs tys rdu clt --decode",
+			"metar-tool obs @morning --decode --runways",
+			"metar-tool obs KTYS --hours 6 --json --pretty",
+			"metar-tool obs --near home --count 5 --decode",
+			"metar-tool obs --radius 30 --center 35.82,-83.99",
+		},
+		setup: func(opt *options, args []string) ([]string, error) {
+			for _, a := range args {
+				opt.obs = append(opt.obs, splitStations(a)...)
+			}
+			return nil, nil
+		},
+	},
+	{
+		name:    "taf",
+		args:    "[STATION]",
+		summary: "The current TAF for a station, raw, as JSON, decoded or as an hourly timeline",
+		flags:   []string{"json", "decode", "format", "pretty", "timeline", "units"},
+		examples: []string{
+			"metar-tool taf KTYS",
+			"metar-tool taf KTYS --decode",
+			"metar-tool taf tys --timeline",
+			"metar-tool taf KTYS | metar-tool decode",
+		},
+		setup: func(opt *options, args []string) ([]string, error) {
+			if len(args) > 1 {
+				return nil, fmt.Errorf("taf takes one station, got %d", len(args))
+			}
+			if len(args) == 1 {
+				opt.taf = args[0]
+			}
+			return nil, nil
+		},
+	},
+	{
+		name:    "afd",
+		args:    "[WFO]",
+		summary: "The latest NWS Area Forecast Discussion for a forecast office",
+		examples: []string{
+			"metar-tool afd mrx",
+			"metar-tool afd   # the default wfo from the config file",
+		},
+		setup: func(opt *options, args []string) ([]string, error) {
+			if len(args) > 1 {
+				return nil, fmt.Errorf("afd takes one forecast office, got %d", len(args))
+			}
+			opt.forecast = "nws"
+			return args, nil
+		},
+	},
+	{
+		name:    "decode",
+		summary: "Decode METAR, TAF or aviationweather.gov JSON piped on stdin",
+		flags:   append([]string{"timeline", "enrich", "ref-date"}, decodedFlags...),
+		examples: []string{
+			"metar-tool obs KTYS | metar-tool decode",
+			"metar-tool obs KTYS --json | metar-tool decode --format json --pretty",
+			"metar-tool taf KTYS | metar-tool decode --timeline",
+			"metar-tool decode --enrich --derived < reports.txt",
+			"metar-tool decode --ref-date 2026-01-15 < archived-metars.txt",
+		},
+		setup: func(opt *options, args []string) ([]string, error) {
+			if len(args) > 0 {
+				return nil, fmt.Errorf("decode reads stdin and takes no arguments")
+			}
+			opt.decode = true
+			return nil, nil
+		},
+	},
+	{
+		name:    "station",
+		args:    "[STATION|@group]...",
+		summary: "Name, location, elevation and time zone of stations",
+		flags:   []string{"json", "pretty"},
+		examples: []string{
+			"metar-tool station KTYS",
+			"metar-tool station tys anc --json --pretty",
+		},
+		setup: func(opt *options, args []string) ([]string, error) {
+			for _, a := range args {
+				opt.info = append(opt.info, splitStations(a)...)
+			}
+			return nil, nil
+		},
+	},
+	{
+		name:    "check",
+		args:    "[STATION|@group]...",
+		summary: fmt.Sprintf("Go/no-go check of the METAR and TAF against personal minimums (exit status %d on no-go)", noGoExitCode),
+		flags:   []string{"check-hours", "stale-after"},
+		examples: []string{
+			"metar-tool check KTYS",
+			"metar-tool check @morning --check-hours 4 || echo no-go",
+		},
+		setup: func(opt *options, args []string) ([]string, error) {
+			for _, a := range args {
+				opt.obs = append(opt.obs, splitStations(a)...)
+			}
+			opt.check = true
+			return nil, nil
+		},
+	},
+}
+
+// findCommand returns the command called name.
+func findCommand(name string) (command, bool) {
+	for _, c := range commands {
+		if c.name == name {
+			return c, true
+		}
+	}
+	return command{}, false
+}
+
+// runCommand runs "metar-tool name args...", including the version and help
+// commands.
+func runCommand(name string, args []string) {
+	switch name {
+	case "version":
+		fmt.Printf("%s %s\n", "metar-tool", Version)
+		return
+	case "help":
+		if len(args) == 0 {
+			printCommands(os.Stdout)
+			return
+		}
+		c, ok := findCommand(args[0])
+		if !ok {
+			commandUsageAndExit(fmt.Sprintf("unknown command %q", args[0]))
+		}
+		var opt options
+		printCommandHelp(c, commandFlagSet(c, &opt), os.Stdout)
+		return
+	}
+
+	c, ok := findCommand(name)
+	if !ok {
+		commandUsageAndExit(fmt.Sprintf("unknown command %q", name))
+	}
+	opt := options{command: c.name}
+	fs := commandFlagSet(c, &opt)
+	pos, err := parseInterspersed(fs, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		os.Exit(2) // the flag package has printed the error and the help
+	}
+	rest, err := c.setup(&opt, pos)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "ERROR:", err)
+		fmt.Fprintln(os.Stderr)
+		printCommandHelp(c, fs, os.Stderr)
+		os.Exit(2)
+	}
+	run(opt, visitedFlags(fs), rest)
+}
+
+// commandFlagSet defines the flags c takes, with the same names, defaults
+// and help as the flag forms.
+func commandFlagSet(c command, opt *options) *flag.FlagSet {
+	all := flag.NewFlagSet("metar-tool", flag.ContinueOnError)
+	addFlags(all, opt)
+
+	fs := flag.NewFlagSet("metar-tool "+c.name, flag.ContinueOnError)
+	for _, name := range slices.Concat(c.flags, commonFlags) {
+		f := all.Lookup(name)
+		if f == nil {
+			panic("command " + c.name + ": no flag " + name)
+		}
+		fs.Var(f.Value, f.Name, f.Usage)
+	}
+	fs.SetOutput(os.Stderr)
+	fs.Usage = func() { printCommandHelp(c, fs, os.Stderr) }
+	return fs
+}
+
+// parseInterspersed parses flags wherever they appear among the arguments,
+// as in "metar-tool obs KTYS --decode", and returns the other arguments.
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var pos []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos, nil
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+// printCommandHelp prints the usage, flags and examples of c.
+func printCommandHelp(c command, fs *flag.FlagSet, w io.Writer) {
+	synopsis := "metar-tool " + c.name + " [flags]"
+	if c.args != "" {
+		synopsis += " " + c.args
+	}
+	fmt.Fprintf(w, "Usage: %s\n\n%s.\n\nFlags:\n", synopsis, c.summary)
+	fs.SetOutput(w)
+	fs.PrintDefaults()
+	if len(c.examples) > 0 {
+		fmt.Fprintln(w, "\nExamples:")
+		for _, e := range c.examples {
+			fmt.Fprintf(w, " %s\n", e)
+		}
+	}
+}
+
+// printCommands lists the commands for "metar-tool help".
+func printCommands(w io.Writer) {
+	fmt.Fprintln(w, "Usage: metar-tool COMMAND [flags] [arguments]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, " %-8s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintf(w, " %-8s %s\n", "version", "Print the version")
+	fmt.Fprintf(w, " %-8s %s\n", "help", "Show the help of a command: metar-tool help obs")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command, metar-tool takes the flag forms (--obs KTYS, --taf KTYS,")
+	fmt.Fprintln(w, "--decode, ...); with no arguments at all it shows the default station's METAR.")
+	fmt.Fprintln(w, `Flags and arguments may be mixed: "metar-tool obs KTYS --decode".`)
+}
+
+func commandUsageAndExit(msg string) {
+	fmt.Fprintln(os.Stderr, "ERROR:", msg)
+	fmt.Fprintln(os.Stderr)
+	printCommands(os.Stderr)
+	os.Exit(2)
+}
+
+// commandNames lists the command names for error messages.
+func commandNames() string {
+	var names []string
+	for _, c := range commands {
+		names = append(names, c.name)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// configEnv names the config file when --config is not given.
+const configEnv = "METAR_TOOL_CONFIG"
+
+// config is a parsed settings file: sections of key = value pairs, keyed by
+// lower-case names. Keys before the first [section] belong to section "".
+type config map[string]map[string]string
+
+// defaultConfigPath is metar-tool/config under the user configuration
+// directory ($XDG_CONFIG_HOME, usually ~/.config).
+func defaultConfigPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "metar-tool", "config"), nil
+}
+
+// loadConfig reads the settings file at path (--config), else the one
+// $METAR_TOOL_CONFIG names, else the one at the default path. A missing
+// default file is an empty configuration; a missing file that was asked for
+// is an error.
+func loadConfig(path string) (config, string, error) {
+	if path == "" {
+		path = strings.TrimSpace(os.Getenv(configEnv))
+	}
+	explicit := path != ""
+	if !explicit {
+		p, err := defaultConfigPath()
+		if err != nil {
+			return config{}, "", nil
+		}
+		path = p
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		if !explicit && errors.Is(err, fs.ErrNotExist) {
+			return config{}, path, nil
+		}
+		return nil, path, fmt.Errorf("read config: %w", err)
+	}
+	defer f.Close()
+	c, err := parseConfig(f, path)
+	if err != nil {
+		return nil, path, err
+	}
+	for section, keys := range c {
+		switch section {
+		case "", "groups", "minimums":
+		default:
+			return nil, path, fmt.Errorf("%s: unknown section [%s]", path, section)
+		}
+		if section != "" {
+			continue
+		}
+		for k := range keys {
+			if !slices.ContainsFunc(settings, func(s setting) bool { return s.key == k }) {
+				return nil, path, fmt.Errorf("%s: unknown setting %q", path, k)
+			}
+		}
+	}
+	return c, path, nil
+}
+
+// parseConfig reads INI-style settings:
+//
+//	# comment
+//	[minimums]
+//	ceiling = 3000   # trailing comments are allowed
+func parseConfig(r io.Reader, name string) (config, error) {
+	c := config{}
+	section := ""
+	sc := bufio.NewScanner(r)
+	for n := 1; sc.Scan(); n++ {
+		ln := sc.Text()
+		if i := strings.Index(ln, "#"); i >= 0 {
+			ln = ln[:i]
+		}
+		ln = strings.TrimSpace(ln)
+		switch {
+		case ln == "" || strings.HasPrefix(ln, ";"):
+			continue
+		case strings.HasPrefix(ln, "["):
+			if !strings.HasSuffix(ln, "]") {
+				return nil, fmt.Errorf("%s:%d: bad section header %q", name, n, ln)
+			}
+			section = strings.ToLower(strings.TrimSpace(ln[1 : len(ln)-1]))
+			continue
+		}
+		key, value, ok := strings.Cut(ln, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("%s:%d: expected key = value, got %q", name, n, ln)
+		}
+		if c[section] == nil {
+			c[section] = map[string]string{}
+		}
+		c[section][key] = strings.TrimSpace(value)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", name, err)
+	}
+	return c, nil
+}
+
+// parseConfigBool accepts yes/no, true/false and on/off.
+func parseConfigBool(s string) (bool, error) {
+	switch strings.ToLower(s) {
+	case "yes", "true", "on":
+		return true, nil
+	case "no", "false", "off":
+		return false, nil
+	}
+	return false, fmt.Errorf("expected yes or no, got %q", s)
+}
+
+// setting is a default that comes from its flag, else its environment
+// variable, else the top of the config file, else the built-in value.
+type setting struct {
+	key   string // in the config file
+	env   string
+	flag  string // "" when the setting has no flag
+	apply func(opt *options, v string) error
+}
+
+var settings = []setting{
+	{"user_agent", "METAR_TOOL_USER_AGENT", "user-agent", func(opt *options, v string) error {
+		opt.userAgent = v
+		return nil
+	}},
+	{"timeout", "METAR_TOOL_TIMEOUT", "timeout", func(opt *options, v string) error {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("expected a duration such as 10s, got %q", v)
+		}
+		opt.timeout = d
+		return nil
+	}},
+	{"station", "METAR_TOOL_STATION", "", func(opt *options, v string) error {
+		opt.station = v
+		return nil
+	}},
+	{"wfo", "METAR_TOOL_WFO", "", func(opt *options, v string) error {
+		opt.wfo = v
+		return nil
+	}},
+	{"home", homeEnv, "", func(opt *options, v string) error {
+		p, err := parseLatLon(v)
+		if err != nil {
+			return err
+		}
+		opt.home = &p
+		return nil
+	}},
+	{"units", "METAR_TOOL_UNITS", "units", func(opt *options, v string) error {
+		if _, err := parseUnits(v); err != nil {
+			return err
+		}
+		opt.units = v
+		return nil
+	}},
+	{"format", "METAR_TOOL_FORMAT", "format", func(opt *options, v string) error {
+		if f := strings.ToLower(v); f != "text" && f != "json" {
+			return fmt.Errorf(`expected "text" or "json", got %q`, v)
+		}
+		opt.format = v
+		return nil
+	}},
+	{"pretty", "METAR_TOOL_PRETTY", "pretty", func(opt *options, v string) error {
+		b, err := parseConfigBool(v)
+		opt.pretty = b
+		return err
+	}},
+}
+
+// applySettings fills in the settings whose flags were not given (set holds
+// the names of those that were) from the environment and then from the
+// config file read from path.
+func applySettings(opt *options, set map[string]bool, c config, path string) error {
+	for _, s := range settings {
+		if s.flag != "" && set[s.flag] {
+			continue
+		}
+		if v := strings.TrimSpace(os.Getenv(s.env)); v != "" {
+			if err := s.apply(opt, v); err != nil {
+				return fmt.Errorf("$%s: %w", s.env, err)
+			}
+			continue
+		}
+		if v, ok := c[""][s.key]; ok && v != "" {
+			if err := s.apply(opt, v); err != nil {
+				return fmt.Errorf("%s: %s: %w", path, s.key, err)
+			}
+		}
+	}
+	return nil
+}
+
+// expandGroups replaces @name entries with the stations of the [groups]
+// entry name in the config file.
+func expandGroups(ids []string, c config, path string) ([]string, error) {
+	var out []string
+	for _, id := range ids {
+		name, ok := strings.CutPrefix(id, "@")
+		if !ok {
+			out = append(out, id)
+			continue
+		}
+		group, ok := c["groups"][strings.ToLower(name)]
+		if !ok {
+			if path == "" {
+				return nil, fmt.Errorf("unknown station group %s (no config file)", id)
+			}
+			return nil, fmt.Errorf("unknown station group %s (define it under [groups] in %s)", id, path)
+		}
+		stations := splitStations(group)
+		if len(stations) == 0 {
+			return nil, fmt.Errorf("station group %s in %s is empty", id, path)
+		}
+		out = append(out, stations...)
+	}
+	return out, nil
+}
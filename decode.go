@@ -3,31 +3,65 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"os"
+	"slices"
 	"strings"
+	"time"
 )
 
-func decodeFromStdin(in []byte) error {
+// decodeOptions controls how --decode renders its input.
+type decodeOptions struct {
+	format   string // "text" or "json"
+	pretty   bool
+	timeline bool         // TAF input: print the hour-by-hour timeline
+	units    displayUnits // text output only; JSON keeps the reported units
+	ref      time.Time    // resolves DDHHMMZ days in raw input; zero means now
+	stale    *staleCheck  // nil or unused for archived input
+	derived  bool         // text output: add the Derived section
+	runways  bool         // add the wind components for each runway
+	xwindKt  float64      // crosswind limit in knots for runways, 0 for none
+	origin   *latLon      // area or --near search point: JSON gives each station's distance from it
+
+	// enrich looks up station metadata for raw METAR input, using the HTTP
+	// settings below.
+	enrich    bool
+	timeout   time.Duration
+	userAgent string
+}
+
+func decodeFromStdin(in []byte, do decodeOptions) error {
 	s := strings.TrimSpace(string(in))
 
 	// Heuristic JSON detection
 	if len(s) > 0 && (s[0] == '{' || s[0] == '[') {
-		// Try aviationweather JSON array
-		var arr []awMetar
-		if err := json.Unmarshal([]byte(s), &arr); err == nil && len(arr) > 0 {
-			for i, m := range arr {
-				if i > 0 {
-					fmt.Println()
-				}
-				printHumanFromAWJSON(m)
+		// Try aviationweather TAF JSON
+		if raws := tafsFromJSON(s); len(raws) > 0 {
+			if err := decodeTAFText(strings.Join(raws, "\n\n"), do); err != nil {
+				return fmt.Errorf("%w on stdin", err)
 			}
 			return nil
 		}
 
+		// Try aviationweather JSON array
+		var arr []awMetar
+		err := json.Unmarshal([]byte(s), &arr)
+		if err == nil && len(arr) > 0 && (arr[0].ICAOId != "" || arr[0].RawOb != "") {
+			return decodeAWMetars(arr, do)
+		}
+
+		if err != nil && s[0] == '[' && strings.Contains(s, `"rawOb"`) {
+			return fmt.Errorf("decode aviationweather.gov METAR JSON: %w", err)
+		}
+
 		// Try single object
 		var obj awMetar
-		if err := json.Unmarshal([]byte(s), &obj); err == nil && strings.TrimSpace(obj.RawOb) != "" {
-			printHumanFromAWJSON(obj)
-			return nil
+		err = json.Unmarshal([]byte(s), &obj)
+		if err == nil && strings.TrimSpace(obj.RawOb) != "" {
+			return decodeAWMetars([]awMetar{obj}, do)
+		}
+
+		if err != nil && s[0] == '{' && strings.Contains(s, `"rawOb"`) {
+			return fmt.Errorf("decode aviationweather.gov METAR JSON: %w", err)
 		}
 
 		// Fallback: pretty-print arbitrary JSON
@@ -35,6 +69,9 @@ func decodeFromStdin(in []byte) error {
 		if err := json.Unmarshal([]byte(s), &v); err != nil {
 			return fmt.Errorf("stdin looked like JSON but could not decode: %w", err)
 		}
+		if do.format == "json" {
+			return fmt.Errorf("stdin JSON does not contain METAR observations")
+		}
 		out, err := json.MarshalIndent(v, "", "  ")
 		if err != nil {
 			return fmt.Errorf("re-encode JSON: %w", err)
@@ -43,8 +80,178 @@ func decodeFromStdin(in []byte) error {
 		return nil
 	}
 
-	// Otherwise treat as raw METAR
-	return decodeRawMETARToHuman(s)
+	if looksLikeTAF(s) {
+		if err := decodeTAFText(s, do); err != nil {
+			return fmt.Errorf("%w on stdin", err)
+		}
+		return nil
+	}
+
+	// Otherwise treat as raw METAR, one or more reports
+	var obs []*Observation
+	for _, r := range splitMETARs(s) {
+		o, err := ParseMETAR(r)
+		if err != nil {
+			return fmt.Errorf("%w on stdin", err)
+		}
+		obs = append(obs, o)
+	}
+	for _, o := range obs {
+		o.resolveTime(do.ref)
+	}
+	if do.enrich {
+		enrichObservations(obs, do.timeout, do.userAgent)
+	}
+	if do.format == "json" {
+		if do.runways {
+			for _, o := range obs {
+				o.Runways = runwayWinds(o, do.xwindKt)
+			}
+		}
+		return printDecodedJSON(obs, do.pretty)
+	}
+	for i, o := range obs {
+		if i > 0 {
+			fmt.Println()
+		}
+		printObservation(o, do)
+	}
+	return nil
+}
+
+// refTime is the reference time for day-of-month groups in raw input.
+func (do decodeOptions) refTime() time.Time {
+	if do.ref.IsZero() {
+		return time.Now()
+	}
+	return do.ref
+}
+
+// enrichObservations attaches station metadata to observations that lack it.
+// A failed lookup is reported on stderr and leaves the output undecorated.
+func enrichObservations(obs []*Observation, timeout time.Duration, userAgent string) {
+	var ids []string
+	for _, o := range obs {
+		if o.Info == nil && validStation(o.Station) && !slices.Contains(ids, o.Station) {
+			ids = append(ids, o.Station)
+		}
+	}
+	if len(ids) == 0 {
+		return
+	}
+	found, err := lookupStations(ids, timeout, userAgent)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "WARNING: station lookup failed: %v\n", err)
+		return
+	}
+	for _, o := range obs {
+		if s, ok := found[o.Station]; ok && o.Info == nil {
+			o.Info = &s
+		}
+	}
+}
+
+// splitMETARs splits text holding one or more METAR/SPECI reports, one per
+// line as aviationweather.gov returns them. A line that does not start a new
+// report (no DDHHMMZ group after the station) continues the previous one.
+func splitMETARs(s string) []string {
+	var reports []string
+	for _, ln := range strings.Split(s, "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln == "" {
+			continue
+		}
+		if len(reports) == 0 || startsMETAR(ln) {
+			reports = append(reports, ln)
+			continue
+		}
+		reports[len(reports)-1] += " " + ln
+	}
+	return reports
+}
+
+func startsMETAR(line string) bool {
+	f := strings.Fields(line)
+	if len(f) > 0 && (f[0] == "METAR" || f[0] == "SPECI") {
+		return true
+	}
+	if len(f) > 1 {
+		_, _, _, ok := parseDDHHMMZ(f[1])
+		return ok
+	}
+	return false
+}
+
+// decodeAWMetars prints aviationweather.gov METAR records in the format do
+// selects.
+func decodeAWMetars(arr []awMetar, do decodeOptions) error {
+	stale := flagStale(do.stale, arr, awStation, awObsTime)
+	if do.format == "json" {
+		var obs []*Observation
+		for i, m := range arr {
+			o := observationFromAW(m)
+			o.Stale = stale[i]
+			o.Origin = do.origin
+			if do.runways {
+				o.Runways = runwayWinds(o, do.xwindKt)
+			}
+			obs = append(obs, o)
+		}
+		return printDecodedJSON(obs, do.pretty)
+	}
+	for i, m := range arr {
+		if i > 0 {
+			fmt.Println()
+		}
+		printHumanFromAWJSON(m, stale[i], do)
+	}
+	return nil
+}
+
+func awStation(m awMetar) string    { return m.ICAOId }
+func awObsTime(m awMetar) time.Time { return m.ObsTime.Time }
+
+// tafsFromJSON returns the rawTAF fields of aviationweather TAF JSON (an
+// array or a single object), or nil when s is not TAF JSON.
+func tafsFromJSON(s string) []string {
+	var arr []awTAF
+	if err := json.Unmarshal([]byte(s), &arr); err != nil {
+		var obj awTAF
+		if err := json.Unmarshal([]byte(s), &obj); err != nil {
+			return nil
+		}
+		arr = []awTAF{obj}
+	}
+	var raws []string
+	for _, t := range arr {
+		if strings.TrimSpace(t.RawTAF) != "" {
+			raws = append(raws, strings.TrimSpace(t.RawTAF))
+		}
+	}
+	return raws
+}
+
+func decodeTAFText(s string, do decodeOptions) error {
+	if do.format == "json" || do.timeline {
+		tafs, err := parseTAFs(s)
+		if len(tafs) == 0 {
+			return err
+		}
+		if do.format == "json" {
+			if jerr := printDecodedTAFJSON(tafs, do.pretty); jerr != nil {
+				return jerr
+			}
+			return err
+		}
+		for i, t := range tafs {
+			if i > 0 {
+				fmt.Println()
+			}
+			printTAFTimeline(t, do.refTime(), do.units)
+		}
+		return err
+	}
+	return decodeRawTAFToHuman(s, do.units)
 }
 
 // normalizeWFO accepts inputs like "mrx", "MRX", "kmrx" and returns "MRX".
@@ -9,7 +9,7 @@ func decodeCloudCover(code string) string {
 	case "SKC":
 		return "Sky clear"
 	case "CLR":
-		return "Clear below 12,000 ft"
+		return "Clear"
 	case "FEW":
 		return "Few clouds"
 	case "SCT":
@@ -20,6 +20,8 @@ func decodeCloudCover(code string) string {
 		return "Overcast"
 	case "VV":
 		return "Vertical visibility"
+	case "///":
+		return "Cloud cover not reported"
 	default:
 		if strings.TrimSpace(code) == "" {
 			return "Sky condition unknown"
@@ -64,23 +66,26 @@ func decodeWxToken(tok string) string {
 
 	// Descriptor (can appear before precip)
 	desc := ""
+	descCode := ""
 	for _, d := range []string{"MI", "PR", "BC", "DR", "BL", "SH", "TS", "FZ"} {
 		if strings.HasPrefix(t, d) {
-			desc = map[string]string{
-				"MI": "Shallow ",
-				"PR": "Partial ",
-				"BC": "Patches of ",
-				"DR": "Low drifting ",
-				"BL": "Blowing ",
-				"SH": "Showers of ",
-				"TS": "Thunderstorm with ",
-				"FZ": "Freezing ",
-			}[d]
+			desc = decodeWxDescriptor(d) + " "
+			descCode = d
 			t = strings.TrimPrefix(t, d)
 			break
 		}
 	}
 
+	// Descriptor on its own, e.g. TS or VCSH
+	if t == "" && descCode != "" {
+		switch descCode {
+		case "TS":
+			return strings.TrimSpace(prox + intensity + "Thunderstorm")
+		case "SH":
+			return strings.TrimSpace(prox + intensity + "Showers")
+		}
+	}
+
 	phen := decodeWxPhenomena(t)
 	if phen == "" {
 		// unknown token: keep original-ish meaning
@@ -90,6 +95,29 @@ func decodeWxToken(tok string) string {
 	return strings.TrimSpace(prox + intensity + desc + phen)
 }
 
+func decodeWxDescriptor(code string) string {
+	switch code {
+	case "MI":
+		return "Shallow"
+	case "PR":
+		return "Partial"
+	case "BC":
+		return "Patches of"
+	case "DR":
+		return "Low drifting"
+	case "BL":
+		return "Blowing"
+	case "SH":
+		return "Showers of"
+	case "TS":
+		return "Thunderstorm with"
+	case "FZ":
+		return "Freezing"
+	default:
+		return ""
+	}
+}
+
 func decodeWxPhenomena(code string) string {
 	m := map[string]string{
 		// precip
@@ -1,69 +1,218 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"math"
+	"strconv"
 	"strings"
 	"time"
 )
 
 type awCloud struct {
-	Cover string `json:"cover"` // FEW/SCT/BKN/OVC/VV
-	Base  *int   `json:"base"`  // feet AGL (often)
+	Cover string   `json:"cover"` // FEW/SCT/BKN/OVC/VV
+	Base  awNumber `json:"base"`  // feet AGL
 }
 
+// awMetar is one record of the aviationweather.gov METAR JSON. Numeric
+// fields use awNumber because the API sends some of them as strings ("10+")
+// and any of them may be null.
 type awMetar struct {
-	RawOb    string    `json:"rawOb"`
-	ICAOId   string    `json:"icaoId"`
-	ObsTime  string    `json:"obsTime"`
-	WDir     *int      `json:"wdir"`
-	WSpd     *int      `json:"wspd"`
-	WGst     *int      `json:"wgst"`
-	Visib    *string   `json:"visib"`
-	Altim    *string   `json:"altim"`
-	Temp     *string   `json:"temp"`
-	Dewp     *string   `json:"dewp"`
-	WxString *string   `json:"wxString"`
-	Clouds   []awCloud `json:"clouds"`
+	ICAOId      string    `json:"icaoId"`
+	ReceiptTime awTime    `json:"receiptTime"`
+	ObsTime     awTime    `json:"obsTime"` // Unix seconds
+	ReportTime  awTime    `json:"reportTime"`
+	Temp        awNumber  `json:"temp"`  // °C
+	Dewp        awNumber  `json:"dewp"`  // °C
+	WDir        awNumber  `json:"wdir"`  // degrees true, or "VRB"
+	WSpd        awNumber  `json:"wspd"`  // kt
+	WGst        awNumber  `json:"wgst"`  // kt
+	Visib       awNumber  `json:"visib"` // statute miles, "10+" for 10 or more
+	Altim       awNumber  `json:"altim"` // hPa
+	SLP         awNumber  `json:"slp"`   // hPa
+	QCField     int       `json:"qcField"`
+	WxString    string    `json:"wxString"`
+	PresTend    awNumber  `json:"presTend"` // hPa
+	MaxT        awNumber  `json:"maxT"`     // °C
+	MinT        awNumber  `json:"minT"`     // °C
+	MaxT24      awNumber  `json:"maxT24"`   // °C
+	MinT24      awNumber  `json:"minT24"`   // °C
+	Precip      awNumber  `json:"precip"`   // inches
+	Pcp3hr      awNumber  `json:"pcp3hr"`   // inches
+	Pcp6hr      awNumber  `json:"pcp6hr"`   // inches
+	Pcp24hr     awNumber  `json:"pcp24hr"`  // inches
+	Snow        awNumber  `json:"snow"`     // inches
+	VertVis     awNumber  `json:"vertVis"`  // feet
+	MetarType   string    `json:"metarType"`
+	RawOb       string    `json:"rawOb"`
+	Lat         awNumber  `json:"lat"`
+	Lon         awNumber  `json:"lon"`
+	Elev        awNumber  `json:"elev"` // meters
+	Name        string    `json:"name"`
+	Cover       string    `json:"cover"` // highest cover reported
+	Clouds      []awCloud `json:"clouds"`
+	FltCat      string    `json:"fltCat"`
 }
 
-func printHumanFromAWJSON(m awMetar) {
+// awNumber is a numeric API field that may arrive as a JSON number, a string
+// ("10+", "1.5"), or null. Text keeps the value as sent; Value is set when
+// it parses as a number, ignoring a trailing "+".
+type awNumber struct {
+	Text  string
+	Value *float64
+}
+
+func (n *awNumber) UnmarshalJSON(b []byte) error {
+	*n = awNumber{}
+	s := strings.TrimSpace(string(b))
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		s = strings.TrimSpace(s)
+	}
+	n.Text = s
+	if f, err := strconv.ParseFloat(strings.TrimSuffix(s, "+"), 64); err == nil {
+		n.Value = &f
+	}
+	return nil
+}
+
+// Valid reports whether the field held a number.
+func (n awNumber) Valid() bool {
+	return n.Value != nil
+}
+
+// Plus reports a "greater than" value such as visib "10+".
+func (n awNumber) Plus() bool {
+	return strings.HasSuffix(n.Text, "+")
+}
+
+// Int returns the value rounded to a whole number, or nil.
+func (n awNumber) Int() *int {
+	if n.Value == nil {
+		return nil
+	}
+	i := int(math.Round(*n.Value))
+	return &i
+}
+
+// awTime is a timestamp the API sends either as Unix seconds or as an
+// RFC 3339 string. The zero value means it was absent.
+type awTime struct {
+	time.Time
+}
+
+func (t *awTime) UnmarshalJSON(b []byte) error {
+	*t = awTime{}
+	var n awNumber
+	if err := n.UnmarshalJSON(b); err != nil {
+		return err
+	}
+	switch {
+	case n.Text == "":
+		return nil
+	case n.Value != nil:
+		t.Time = time.Unix(int64(*n.Value), 0).UTC()
+	default:
+		parsed, err := time.Parse(time.RFC3339, n.Text)
+		if err != nil {
+			return fmt.Errorf("time %q: %w", n.Text, err)
+		}
+		t.Time = parsed.UTC()
+	}
+	return nil
+}
+
+func printHumanFromAWJSON(m awMetar, stale bool, do decodeOptions) {
+	u := do.units
 	station := strings.TrimSpace(m.ICAOId)
 	if station == "" {
 		station = "(unknown station)"
 	}
 
 	fmt.Printf("Station: %s\n", station)
+	info := stationInfoFromAW(m)
+	if info != nil {
+		for _, ln := range describeStationInfo(info) {
+			fmt.Println(ln)
+		}
+	} else if name := strings.TrimSpace(m.Name); name != "" {
+		fmt.Printf("Name: %s\n", name)
+	}
+	if m.MetarType != "" {
+		fmt.Printf("Report: %s\n", m.MetarType)
+	}
 
-	if t, err := time.Parse(time.RFC3339, strings.TrimSpace(m.ObsTime)); err == nil {
-		fmt.Printf("Observed: %s UTC\n", t.UTC().Format("2006-01-02 15:04"))
-	} else if strings.TrimSpace(m.ObsTime) != "" {
-		fmt.Printf("Observed: %s\n", strings.TrimSpace(m.ObsTime))
+	if !m.ObsTime.IsZero() {
+		var zone string
+		if info != nil {
+			zone = info.TimeZone
+		}
+		for _, ln := range describeObservedTime(m.ObsTime.Time, zone) {
+			fmt.Println(ln)
+		}
+	}
+	if stale {
+		fmt.Println(staleLine)
 	}
 
-	fmt.Printf("Wind: %s\n", humanWindFromJSON(m.WDir, m.WSpd, m.WGst))
+	// The record is decoded through the same Observation as raw text, so
+	// both paths describe wind and visibility alike.
+	o := observationFromAW(m)
+	if o.Wind != nil {
+		fmt.Printf("Wind: %s%s\n", describeWind(o.Wind, u), describeMagneticWind(o))
+	} else {
+		fmt.Println("Wind: unknown")
+	}
 
-	if m.Visib != nil && strings.TrimSpace(*m.Visib) != "" {
-		fmt.Printf("Visibility: %s SM\n", strings.TrimSpace(*m.Visib))
+	switch {
+	case o.Visibility != nil:
+		fmt.Printf("Visibility: %s\n", describeVisibility(o.Visibility, u))
+	case o.CAVOK:
+		fmt.Printf("Visibility: %s\n", describeCAVOK(u))
 	}
 
-	if m.WxString != nil && strings.TrimSpace(*m.WxString) != "" {
-		fmt.Printf("Weather: %s\n", decodeWeatherTokens(strings.TrimSpace(*m.WxString)))
+	if wx := strings.TrimSpace(m.WxString); wx != "" {
+		fmt.Printf("Weather: %s\n", decodeWeatherTokens(wx))
 	}
 
 	if len(m.Clouds) > 0 {
 		var parts []string
+		var sky Conditions
 		for _, c := range m.Clouds {
-			parts = append(parts, humanCloudLayer(c))
+			parts = append(parts, humanCloudLayer(c, u))
+			sky.Sky = append(sky.Sky, skyLayerFromAW(c))
 		}
 		fmt.Printf("Sky: %s\n", strings.Join(parts, ", "))
+		fmt.Printf("Ceiling: %s\n", describeCeiling(&sky, u))
 	}
 
-	if (m.Temp != nil && strings.TrimSpace(*m.Temp) != "") || (m.Dewp != nil && strings.TrimSpace(*m.Dewp) != "") {
-		fmt.Printf("Temp/Dew: %s°C / %s°C\n", nonEmptyPtr(m.Temp, "?"), nonEmptyPtr(m.Dewp, "?"))
+	if o.HasCategoryInputs() {
+		fmt.Printf("Flight category: %s\n", describeFlightCategory(o))
 	}
 
-	if m.Altim != nil && strings.TrimSpace(*m.Altim) != "" {
-		fmt.Printf("Altimeter: %s inHg\n", strings.TrimSpace(*m.Altim))
+	if m.Temp.Valid() || m.Dewp.Valid() {
+		fmt.Printf("Temp/Dew: %s / %s\n", humanTempFromJSON(m.Temp, u), humanTempFromJSON(m.Dewp, u))
+	}
+
+	if m.Altim.Valid() {
+		fmt.Printf("Altimeter: %s\n", u.pressure(*m.Altim.Value, "hPa", true))
+	}
+	if m.SLP.Valid() {
+		fmt.Printf("Sea-level pressure: %s\n", u.pressure(*m.SLP.Value, "hPa", true))
+	}
+	if m.Precip.Valid() {
+		fmt.Printf("Precipitation: %s\n", describePrecipIn(*m.Precip.Value, u))
+	}
+	if do.derived {
+		printDerived(o, u)
+	}
+	if do.runways {
+		printRunwayWinds(o, do.xwindKt, u)
 	}
 
 	if strings.TrimSpace(m.RawOb) != "" {
@@ -71,35 +220,22 @@ func printHumanFromAWJSON(m awMetar) {
 	}
 }
 
-func nonEmptyPtr(p *string, fallback string) string {
-	if p == nil {
-		return fallback
+func humanTempFromJSON(n awNumber, u displayUnits) string {
+	if n.Valid() {
+		return u.tempTenths(*n.Value)
 	}
-	if strings.TrimSpace(*p) == "" {
-		return fallback
+	if u.Temp == "F" {
+		return "?°F"
 	}
-	return strings.TrimSpace(*p)
+	return "?°C"
 }
 
-func humanWindFromJSON(wdir, wspd, wgst *int) string {
-	if wspd == nil {
-		return "unknown"
-	}
-	dir := "VRB"
-	if wdir != nil && *wdir >= 0 {
-		dir = fmt.Sprintf("%03d°", *wdir)
-	}
-	if wgst != nil && *wgst > 0 {
-		return fmt.Sprintf("%s %d kt gusting %d kt", dir, *wspd, *wgst)
-	}
-	return fmt.Sprintf("%s %d kt", dir, *wspd)
+func humanCloudLayer(c awCloud, u displayUnits) string {
+	return describeSkyLayer(skyLayerFromAW(c), u)
 }
 
-func humanCloudLayer(c awCloud) string {
-	cover := strings.TrimSpace(strings.ToUpper(c.Cover))
-	base := ""
-	if c.Base != nil && *c.Base > 0 {
-		base = fmt.Sprintf(" at %d ft AGL", *c.Base)
-	}
-	return fmt.Sprintf("%s%s", decodeCloudCover(cover), base)
+// skyLayerFromAW converts an aviationweather.gov cloud object so the JSON
+// path shares the raw path's sky descriptions and ceiling rules.
+func skyLayerFromAW(c awCloud) SkyLayer {
+	return SkyLayer{Cover: strings.TrimSpace(strings.ToUpper(c.Cover)), Base: c.Base.Int()}
 }
@@ -6,193 +6,305 @@ import (
 	"strings"
 )
 
-func decodeRawMETARToHuman(raw string) error {
-	// If multiple lines, take the first non-empty line.
-	lines := strings.Split(raw, "\n")
-	var metarLine string
-	for _, ln := range lines {
-		ln = strings.TrimSpace(ln)
-		if ln != "" {
-			metarLine = ln
-			break
+// printObservation renders a parsed METAR in the same line-oriented format
+// used for the aviationweather.gov JSON input, with values shown in the
+// --units selection and, with --derived, the computed values.
+func printObservation(o *Observation, do decodeOptions) {
+	u := do.units
+	if o.Station != "" {
+		fmt.Printf("Station: %s\n", o.Station)
+	}
+	if o.Info != nil {
+		for _, ln := range describeStationInfo(o.Info) {
+			fmt.Println(ln)
 		}
 	}
-	if metarLine == "" {
-		return fmt.Errorf("no METAR content found on stdin")
+	if o.ReportType != "" {
+		fmt.Printf("Report: %s\n", o.ReportType)
 	}
-
-	tokens := strings.Fields(metarLine)
-	if len(tokens) < 3 {
-		fmt.Printf("Raw: %s\n", metarLine)
-		return nil
+	switch {
+	case !o.ObservedAt.IsZero():
+		var zone string
+		if o.Info != nil {
+			zone = o.Info.TimeZone
+		}
+		for _, ln := range describeObservedTime(o.ObservedAt, zone) {
+			fmt.Println(ln)
+		}
+	case o.Time != "":
+		fmt.Printf("Observed: %s (DDHHMMZ)\n", o.Time)
+	}
+	if o.Stale {
+		fmt.Println(staleLine)
 	}
 
-	i := 0
-	reportType := ""
-	if tokens[i] == "METAR" || tokens[i] == "SPECI" {
-		reportType = tokens[i]
-		i++
+	switch o.Modifier {
+	case "AUTO":
+		fmt.Printf("Modifier: Automated\n")
+	case "COR":
+		fmt.Printf("Modifier: Corrected\n")
+	}
+	if o.Missing {
+		fmt.Println("Status: No report filed (NIL)")
 	}
 
-	station := tokens[i]
-	i++
-	obsTime := tokens[i]
-	i++
+	if o.Wind != nil {
+		fmt.Printf("Wind: %s%s\n", describeWind(o.Wind, u), describeMagneticWind(o))
+		if v := o.Wind.Variation; v != nil {
+			fmt.Printf("Wind variation: %03dV%03d\n", v.From, v.To)
+		}
+	}
 
-	fmt.Printf("Station: %s\n", station)
-	if reportType != "" {
-		fmt.Printf("Report: %s\n", reportType)
+	if o.Visibility != nil {
+		fmt.Printf("Visibility: %s\n", describeVisibility(o.Visibility, u))
+	}
+	if o.CAVOK {
+		fmt.Printf("Visibility: %s\n", describeCAVOK(u))
 	}
-	fmt.Printf("Observed: %s (DDHHMMZ)\n", obsTime)
 
-	// Optional AUTO/COR
-	if i < len(tokens) && (tokens[i] == "AUTO" || tokens[i] == "COR") {
-		if tokens[i] == "AUTO" {
-			fmt.Printf("Modifier: Automated\n")
-		} else {
-			fmt.Printf("Modifier: Corrected\n")
-		}
-		i++
+	for _, r := range o.RVR {
+		fmt.Printf("RVR: %s\n", describeRVR(r, u))
 	}
 
-	// Wind
-	if i < len(tokens) && strings.HasSuffix(tokens[i], "KT") {
-		fmt.Printf("Wind: %s\n", decodeWindToken(tokens[i]))
-		i++
-		// optional variable dir 180V240
-		if i < len(tokens) && strings.Contains(tokens[i], "V") && len(tokens[i]) == 7 {
-			fmt.Printf("Wind variation: %s\n", tokens[i])
-			i++
+	if len(o.Weather) > 0 {
+		var parts []string
+		for _, g := range o.Weather {
+			parts = append(parts, decodeWxToken(g.Raw))
 		}
+		fmt.Printf("Weather: %s\n", strings.Join(parts, ", "))
 	}
 
-	// Visibility
-	if i < len(tokens) {
-		vis, used := decodeVisibility(tokens[i:], 0)
-		if used > 0 {
-			fmt.Printf("Visibility: %s\n", vis)
-			i += used
+	if len(o.Sky) > 0 {
+		var parts []string
+		for _, l := range o.Sky {
+			parts = append(parts, describeSkyLayer(l, u))
 		}
+		fmt.Printf("Sky: %s\n", strings.Join(parts, ", "))
+	}
+	if len(o.Sky) > 0 || o.CAVOK {
+		fmt.Printf("Ceiling: %s\n", describeCeiling(&o.Conditions, u))
+	}
+	if o.HasCategoryInputs() {
+		fmt.Printf("Flight category: %s\n", describeFlightCategory(o))
 	}
 
-	// Weather tokens until sky/temps/alt/RMK
-	var wx []string
-	for i < len(tokens) {
-		t := tokens[i]
-		if isSkyToken(t) || isTempDewToken(t) || isAltimeterToken(t) || t == "RMK" {
-			break
-		}
-		wx = append(wx, t)
-		i++
+	if o.TempC != nil || o.DewpointC != nil {
+		fmt.Printf("Temp/Dew: %s / %s\n", u.tempInt(o.TempC), u.tempInt(o.DewpointC))
 	}
-	if len(wx) > 0 {
-		fmt.Printf("Weather: %s\n", decodeWeatherTokens(strings.Join(wx, " ")))
+
+	if o.Altimeter != nil {
+		fmt.Printf("Altimeter: %s\n", describeAltimeter(o.Altimeter, u))
 	}
 
-	// Sky
-	var sky []string
-	for i < len(tokens) {
-		t := tokens[i]
-		if isTempDewToken(t) || isAltimeterToken(t) || t == "RMK" {
-			break
-		}
-		if isSkyToken(t) {
-			sky = append(sky, decodeSkyToken(t))
-			i++
-			continue
-		}
-		break
+	if do.derived {
+		printDerived(o, u)
 	}
-	if len(sky) > 0 {
-		fmt.Printf("Sky: %s\n", strings.Join(sky, ", "))
+	if do.runways {
+		printRunwayWinds(o, do.xwindKt, u)
 	}
 
-	// Temp/Dew
-	if i < len(tokens) && isTempDewToken(tokens[i]) {
-		tc, dc := decodeTempDew(tokens[i])
-		fmt.Printf("Temp/Dew: %s°C / %s°C\n", tc, dc)
-		i++
+	if len(o.Other) > 0 {
+		fmt.Printf("Other: %s\n", strings.Join(o.Other, " "))
 	}
 
-	// Altimeter
-	if i < len(tokens) && isAltimeterToken(tokens[i]) {
-		fmt.Printf("Altimeter: %s\n", decodeAltimeter(tokens[i]))
-		i++
+	if o.NoSigChange {
+		fmt.Printf("Trend: No significant change expected (NOSIG)\n")
+	}
+	for _, g := range o.Trends {
+		fmt.Printf("Trend: %s\n", describeChangeGroup(g))
+		for _, ln := range describeForecastConditions(g, u) {
+			fmt.Printf("  %s\n", ln)
+		}
 	}
 
-	// RMK (raw)
-	for i < len(tokens) {
-		if tokens[i] == "RMK" {
-			fmt.Printf("Remarks: %s\n", strings.Join(tokens[i+1:], " "))
-			break
+	if o.Remarks != "" {
+		fmt.Printf("Remarks: %s\n", o.Remarks)
+		if o.RMK != nil {
+			for _, ln := range describeRemarks(o.RMK, u) {
+				fmt.Printf("  %s\n", ln)
+			}
 		}
-		i++
 	}
 
-	fmt.Printf("Raw: %s\n", metarLine)
-	return nil
+	fmt.Printf("Raw: %s\n", o.Raw)
 }
 
-func decodeWindToken(tok string) string {
-	// 19004KT, VRB03KT, 19012G18KT, 00000KT
-	if !strings.HasSuffix(tok, "KT") {
-		return tok
+func describeWind(w *Wind, u displayUnits) string {
+	if w.Calm() {
+		return "Calm"
+	}
+	dir := fmt.Sprintf("%03d°", w.Direction)
+	if w.Variable {
+		dir = "Variable"
 	}
-	core := strings.TrimSuffix(tok, "KT")
-	gust := ""
-	if strings.Contains(core, "G") {
-		parts := strings.SplitN(core, "G", 2)
-		core = parts[0]
-		gust = parts[1]
+	if w.Gust != nil {
+		return fmt.Sprintf("%s at %s gusting %s", dir, u.speed(w.Speed, w.Unit), u.speed(*w.Gust, w.Unit))
 	}
+	return fmt.Sprintf("%s at %s", dir, u.speed(w.Speed, w.Unit))
+}
 
-	if strings.HasPrefix(core, "VRB") && len(core) >= 5 {
-		spd := core[3:]
-		if gust != "" {
-			return fmt.Sprintf("Variable at %s kt gusting %s kt", spd, gust)
+// describeCAVOK spells out CAVOK, with the 5000 ft cloud limit in the
+// selected height unit.
+func describeCAVOK(u displayUnits) string {
+	return fmt.Sprintf("CAVOK (10 km or more, no cloud below %s, no significant weather)", u.height(5000, "ft"))
+}
+
+func describeVisibility(v *Visibility, u displayUnits) string {
+	if value, unit, ok := u.visibilityIn(v.Value, v.Unit); ok {
+		s := visibilityText(value, unit)
+		switch v.Modifier {
+		case "P":
+			s = "Greater than " + s
+		case "M":
+			s = "Less than " + s
+		}
+		if m := v.Minimum; m != nil {
+			mv, mu, _ := u.visibilityIn(float64(m.Meters), "m")
+			s += fmt.Sprintf(", minimum %s to the %s", visibilityText(mv, mu), m.Direction)
 		}
-		return fmt.Sprintf("Variable at %s kt", spd)
+		return s
 	}
 
-	if len(core) < 5 {
-		return tok
-	}
-	dir := core[:3]
-	spd := core[3:]
-	if dir == "000" && spd == "00" {
-		return "Calm"
+	if v.Unit == "m" {
+		s := fmt.Sprintf("%.0f m", v.Value)
+		if v.Modifier == "P" {
+			s = "10 km or more"
+		}
+		if m := v.Minimum; m != nil {
+			s += fmt.Sprintf(", minimum %d m to the %s", m.Meters, m.Direction)
+		}
+		return s
 	}
-	if gust != "" {
-		return fmt.Sprintf("%s° at %s kt gusting %s kt", dir, spd, gust)
+
+	n := formatFraction(v.Value)
+	switch v.Modifier {
+	case "P":
+		return fmt.Sprintf("Greater than %s statute miles", n)
+	case "M":
+		return fmt.Sprintf("Less than %s statute miles", n)
+	default:
+		return fmt.Sprintf("%s statute miles", n)
 	}
-	return fmt.Sprintf("%s° at %s kt", dir, spd)
 }
 
-func decodeVisibility(tokens []string, start int) (string, int) {
-	if start >= len(tokens) {
-		return "", 0
+// formatFraction renders statute-mile values the way METARs write them,
+// e.g. 1.5 as "1 1/2" and 0.25 as "1/4".
+func formatFraction(v float64) string {
+	whole := int(v)
+	sixteenths := int((v-float64(whole))*16 + 0.5)
+	if sixteenths == 16 {
+		whole++
+		sixteenths = 0
+	}
+	if sixteenths == 0 {
+		return strconv.Itoa(whole)
 	}
-	t0 := tokens[start]
-	if strings.HasSuffix(t0, "SM") {
-		return humanVisSM(t0), 1
+	num, den := sixteenths, 16
+	for num%2 == 0 {
+		num /= 2
+		den /= 2
 	}
-	// "1 1/2SM"
-	if start+1 < len(tokens) && strings.HasSuffix(tokens[start+1], "SM") && looksNumeric(tokens[start]) {
-		return strings.TrimSpace(tokens[start] + " " + humanVisSM(tokens[start+1])), 2
+	if whole == 0 {
+		return fmt.Sprintf("%d/%d", num, den)
 	}
-	return "", 0
+	return fmt.Sprintf("%d %d/%d", whole, num, den)
 }
 
-func humanVisSM(tok string) string {
-	core := strings.TrimSuffix(tok, "SM")
+func describeSkyLayer(l SkyLayer, u displayUnits) string {
+	switch l.Cover {
+	case "SKC":
+		return "Sky clear"
+	case "CLR":
+		return "Clear below " + u.height(12000, "ft")
+	case "NSC":
+		return "No significant clouds"
+	case "NCD":
+		return "No clouds detected"
+	}
+
+	var s string
 	switch {
-	case strings.HasPrefix(core, "P"):
-		return fmt.Sprintf("Greater than %s statute miles", core[1:])
-	case strings.HasPrefix(core, "M"):
-		return fmt.Sprintf("Less than %s statute miles", core[1:])
+	case l.Cover == "VV" && l.Base != nil:
+		s = "Vertical visibility " + u.height(*l.Base, "ft")
+	case l.Cover == "VV":
+		s = "Vertical visibility not reported"
+	case l.Base != nil:
+		s = fmt.Sprintf("%s at %s AGL", decodeCloudCover(l.Cover), u.height(*l.Base, "ft"))
 	default:
-		return fmt.Sprintf("%s statute miles", core)
+		s = decodeCloudCover(l.Cover) + ", base not reported"
+	}
+
+	switch l.Type {
+	case "CB":
+		s += " (cumulonimbus)"
+	case "TCU":
+		s += " (towering cumulus)"
+	}
+	return s
+}
+
+// describeCeiling renders the ceiling (lowest broken, overcast or vertical
+// visibility layer) for the "Ceiling:" line.
+func describeCeiling(c *Conditions, u displayUnits) string {
+	if ceiling := c.Ceiling(); ceiling != nil {
+		return u.height(*ceiling, "ft") + " AGL"
+	}
+	if c.CeilingNotReported() {
+		return "Not reported"
+	}
+	return "None"
+}
+
+func describeRVR(r RunwayVisualRange, u displayUnits) string {
+	unit := "ft"
+	if r.Unit == "m" {
+		unit = "m"
+	}
+	value := func(n int, mod string) string {
+		switch mod {
+		case "P":
+			return "more than " + u.height(n, unit)
+		case "M":
+			return "less than " + u.height(n, unit)
+		default:
+			return u.height(n, unit)
+		}
+	}
+
+	s := "Runway " + r.Runway + " " + value(r.Min, r.MinModifier)
+	if r.Max != nil {
+		s = fmt.Sprintf("Runway %s variable from %s to %s", r.Runway, value(r.Min, r.MinModifier), value(*r.Max, r.MaxModifier))
+	}
+	switch r.Tendency {
+	case "U":
+		s += ", increasing"
+	case "D":
+		s += ", decreasing"
+	case "N":
+		s += ", no change"
+	}
+	return s
+}
+
+func describeAltimeter(a *Altimeter, u displayUnits) string {
+	s := u.pressure(a.Value, a.Unit, false)
+	if a.Unit == "hPa" {
+		s += " (QNH)"
+	}
+	return s
+}
+
+// formatMInt renders a temperature the way the METAR reports it: at least
+// two digits, with a minus sign instead of the M prefix.
+func formatMInt(p *int) string {
+	if p == nil {
+		return "?"
+	}
+	n := *p
+	if n < 0 {
+		return fmt.Sprintf("-%02d", -n)
 	}
+	return fmt.Sprintf("%02d", n)
 }
 
 func looksNumeric(s string) bool {
@@ -212,36 +324,8 @@ func isSkyToken(t string) bool {
 	if t == "SKC" || t == "CLR" || t == "NSC" || t == "NCD" {
 		return true
 	}
-	// FEW050, SCT080, BKN250, OVC010, VV002
-	if len(t) == 6 {
-		p := t[:3]
-		if p == "FEW" || p == "SCT" || p == "BKN" || p == "OVC" || p == "VV" {
-			_, err := strconv.Atoi(t[3:])
-			return err == nil
-		}
-	}
-	return false
-}
-
-func decodeSkyToken(t string) string {
-	t = strings.ToUpper(t)
-	switch t {
-	case "SKC":
-		return "Sky clear"
-	case "CLR":
-		return "Clear below 12,000 ft"
-	case "NSC":
-		return "No significant clouds"
-	case "NCD":
-		return "No clouds detected"
-	}
-	if len(t) == 6 {
-		cover := t[:3]
-		hundreds, _ := strconv.Atoi(t[3:])
-		ft := hundreds * 100
-		return fmt.Sprintf("%s at %d ft AGL", decodeCloudCover(cover), ft)
-	}
-	return t
+	// FEW050, SCT025TCU, BKN030CB, OVC010, VV002, BKN///
+	return reSkyLayer.MatchString(t)
 }
 
 func isTempDewToken(t string) bool {
@@ -271,41 +355,8 @@ func looksSignedInt(s string) bool {
 	return true
 }
 
-func decodeTempDew(t string) (tempC string, dewC string) {
-	parts := strings.SplitN(t, "/", 2)
-	tempC = parseMInt(parts[0])
-	dewC = parseMInt(parts[1])
-	return tempC, dewC
-}
-
-func parseMInt(s string) string {
-    s = strings.TrimSpace(s)
-
-    neg := strings.HasPrefix(s, "M")
-    s = strings.TrimPrefix(s, "M")
-
-    if s == "" {
-        return "?"
-    }
-    if neg {
-        return "-" + s
-    }
-    return s
-}
-
 func isAltimeterToken(t string) bool {
-	// A2969
-	return len(t) == 5 && strings.HasPrefix(strings.ToUpper(t), "A") && looksNumeric(t[1:])
-}
-
-func decodeAltimeter(t string) string {
-	t = strings.ToUpper(t)
-	if !isAltimeterToken(t) {
-		return t
-	}
-	v := t[1:]
-	if len(v) != 4 {
-		return t
-	}
-	return fmt.Sprintf("%s.%s inHg", v[:2], v[2:])
+	// A2969, Q1013
+	u := strings.ToUpper(t)
+	return len(t) == 5 && (strings.HasPrefix(u, "A") || strings.HasPrefix(u, "Q")) && looksNumeric(t[1:])
 }
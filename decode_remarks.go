@@ -0,0 +1,565 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Remarks holds the decoded contents of the RMK section. Groups the decoder
+// does not understand are kept, in order, in Other.
+type Remarks struct {
+	StationType      string   // AO1 or AO2
+	SeaLevelPressure *float64 // hPa
+	SLPNotAvailable  bool     // SLPNO
+	PreciseTempC     *float64 // T group
+	PreciseDewC      *float64
+	HourlyPrecipIn   *float64 // Pnnnn
+	Precip6hIn       *float64 // 6RRRR, 3- or 6-hourly
+	Precip24hIn      *float64 // 7RRRR
+	MaxTemp6hC       *float64 // 1sTTT
+	MinTemp6hC       *float64 // 2sTTT
+	MaxTemp24hC      *float64 // 4sTTTsTTT
+	MinTemp24hC      *float64
+	PressureTendency *PressureTendency
+	PressureChange   string // PRESRR or PRESFR
+	PeakWind         *PeakWind
+	WindShift        *WindShift
+	WeatherEvents    []WeatherEvent
+	Lightning        []Lightning
+	Virga            *Located
+	Clouds           []Located // CB, TCU, ACC, ...
+	Maintenance      bool      // $
+	SensorOutages    []string  // PWINO, TSNO, FZRANO, ...
+	Other            []string
+}
+
+// PressureTendency is the 5appp group: the characteristic of the pressure
+// change over the last three hours and its amount in hPa.
+type PressureTendency struct {
+	Code   int
+	Change float64 // hPa, negative for a fall (codes 5-8)
+}
+
+// PeakWind is the PK WND dddff(f)/(hh)mm group.
+type PeakWind struct {
+	Direction int
+	Speed     int
+	Hour      *int // nil when only minutes were reported
+	Minute    int
+}
+
+// WindShift is the WSHFT (hh)mm [FROPA] group.
+type WindShift struct {
+	Hour    *int
+	Minute  int
+	Frontal bool
+}
+
+// WeatherEvent is one begin or end time from a group such as RAB15E30 or
+// TSB0159.
+type WeatherEvent struct {
+	Weather string // e.g. RA, FZRA, TS
+	Began   bool   // false for an end time
+	Hour    *int
+	Minute  int
+}
+
+// Lightning is an LTG group with its frequency, types and location.
+type Lightning struct {
+	Frequency string   // OCNL, FRQ or CONS
+	Types     []string // IC, CC, CG, CA
+	Location  string
+}
+
+// Located is a phenomenon reported with an optional location and movement,
+// e.g. "CB W MOV E" or "VIRGA SW".
+type Located struct {
+	Type     string
+	Location string
+	Movement string
+}
+
+var (
+	reSLP       = regexp.MustCompile(`^SLP(\d{3})$`)
+	reTGroup    = regexp.MustCompile(`^T([01])(\d{3})(?:([01])(\d{3}))?$`)
+	rePrecip    = regexp.MustCompile(`^([P67])(\d{4}|////)$`)
+	reTemp6h    = regexp.MustCompile(`^([12])([01])(\d{3})$`)
+	reTemp24h   = regexp.MustCompile(`^4([01])(\d{3})([01])(\d{3})$`)
+	reTendency  = regexp.MustCompile(`^5([0-8])(\d{3})$`)
+	rePeakWind  = regexp.MustCompile(`^(\d{3})(\d{2,3})/(\d{2})?(\d{2})$`)
+	reHHMM      = regexp.MustCompile(`^(\d{2})?(\d{2})$`)
+	reEvents    = regexp.MustCompile(`^([A-Z]{2}(?:[A-Z]{2})*?)((?:[BE]\d{2}(?:\d{2})?)+)`)
+	reEventTime = regexp.MustCompile(`([BE])(\d{2})(\d{2})?`)
+	reLightning = regexp.MustCompile(`^LTG((?:IC|CC|CG|CA)*)$`)
+	reDirection = regexp.MustCompile(`^[NSEW]{1,2}(?:-[NSEW]{1,2})*$`)
+)
+
+var sensorOutages = map[string]string{
+	"RVRNO":  "Runway visual range not available",
+	"PWINO":  "Precipitation identifier not available",
+	"PNO":    "Precipitation amount not available",
+	"FZRANO": "Freezing rain sensor not available",
+	"TSNO":   "Lightning sensor not available",
+	"VISNO":  "Secondary visibility sensor not available",
+	"CHINO":  "Secondary ceiling sensor not available",
+}
+
+// parseRemarks decodes the text that follows RMK.
+func parseRemarks(s string) *Remarks {
+	r := &Remarks{}
+	tokens := strings.Fields(s)
+
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+
+		switch {
+		case t == "AO1" || t == "AO2" || t == "AO1A" || t == "AO2A":
+			r.StationType = t[:3]
+			continue
+		case t == "SLPNO":
+			r.SLPNotAvailable = true
+			continue
+		case t == "$":
+			r.Maintenance = true
+			continue
+		case t == "PRESRR" || t == "PRESFR":
+			r.PressureChange = t
+			continue
+		case sensorOutages[t] != "":
+			r.SensorOutages = append(r.SensorOutages, t)
+			continue
+		}
+
+		if m := reSLP.FindStringSubmatch(t); m != nil {
+			tenths, _ := strconv.Atoi(m[1])
+			slp := float64(tenths) / 10
+			if slp < 50 {
+				slp += 1000
+			} else {
+				slp += 900
+			}
+			r.SeaLevelPressure = &slp
+			continue
+		}
+		if m := reTGroup.FindStringSubmatch(t); m != nil {
+			r.PreciseTempC = signedTenths(m[1], m[2])
+			if m[3] != "" {
+				r.PreciseDewC = signedTenths(m[3], m[4])
+			}
+			continue
+		}
+		if m := rePrecip.FindStringSubmatch(t); m != nil {
+			var amount *float64
+			if m[2] != "////" {
+				hundredths, _ := strconv.Atoi(m[2])
+				in := float64(hundredths) / 100
+				amount = &in
+			}
+			switch m[1] {
+			case "P":
+				r.HourlyPrecipIn = amount
+			case "6":
+				r.Precip6hIn = amount
+			case "7":
+				r.Precip24hIn = amount
+			}
+			if amount == nil {
+				r.Other = append(r.Other, t)
+			}
+			continue
+		}
+		if m := reTemp6h.FindStringSubmatch(t); m != nil {
+			if m[1] == "1" {
+				r.MaxTemp6hC = signedTenths(m[2], m[3])
+			} else {
+				r.MinTemp6hC = signedTenths(m[2], m[3])
+			}
+			continue
+		}
+		if m := reTemp24h.FindStringSubmatch(t); m != nil {
+			r.MaxTemp24hC = signedTenths(m[1], m[2])
+			r.MinTemp24hC = signedTenths(m[3], m[4])
+			continue
+		}
+		if m := reTendency.FindStringSubmatch(t); m != nil {
+			code, _ := strconv.Atoi(m[1])
+			tenths, _ := strconv.Atoi(m[2])
+			if code >= 5 { // the pressure is lower than three hours ago
+				tenths = -tenths
+			}
+			r.PressureTendency = &PressureTendency{Code: code, Change: float64(tenths) / 10}
+			continue
+		}
+
+		// PK WND 28045/15
+		if t == "PK" && i+2 < len(tokens) && tokens[i+1] == "WND" {
+			if m := rePeakWind.FindStringSubmatch(tokens[i+2]); m != nil {
+				pk := &PeakWind{}
+				pk.Direction, _ = strconv.Atoi(m[1])
+				pk.Speed, _ = strconv.Atoi(m[2])
+				pk.Hour = optionalInt(m[3])
+				pk.Minute, _ = strconv.Atoi(m[4])
+				r.PeakWind = pk
+				i += 2
+				continue
+			}
+		}
+
+		// WSHFT 1715 FROPA
+		if t == "WSHFT" && i+1 < len(tokens) {
+			if m := reHHMM.FindStringSubmatch(tokens[i+1]); m != nil {
+				ws := &WindShift{Hour: optionalInt(m[1])}
+				ws.Minute, _ = strconv.Atoi(m[2])
+				i++
+				if i+1 < len(tokens) && tokens[i+1] == "FROPA" {
+					ws.Frontal = true
+					i++
+				}
+				r.WindShift = ws
+				continue
+			}
+		}
+
+		// OCNL LTGICCG OHD, LTG DSNT NE-SE
+		freq := ""
+		if (t == "OCNL" || t == "FRQ" || t == "CONS") && i+1 < len(tokens) && reLightning.MatchString(tokens[i+1]) {
+			freq = t
+			i++
+			t = tokens[i]
+		}
+		if m := reLightning.FindStringSubmatch(t); m != nil {
+			l := Lightning{Frequency: freq}
+			for j := 0; j+2 <= len(m[1]); j += 2 {
+				l.Types = append(l.Types, m[1][j:j+2])
+			}
+			l.Location, i = takeLocation(tokens, i+1)
+			r.Lightning = append(r.Lightning, l)
+			continue
+		}
+
+		if t == "VIRGA" {
+			v := &Located{Type: t}
+			v.Location, i = takeLocation(tokens, i+1)
+			r.Virga = v
+			continue
+		}
+		if t == "CB" || t == "TCU" || t == "CBMAM" || t == "ACC" {
+			c := Located{Type: t}
+			c.Location, i = takeLocation(tokens, i+1)
+			if i+2 < len(tokens) && tokens[i+1] == "MOV" {
+				c.Movement = tokens[i+2]
+				i += 2
+			}
+			r.Clouds = append(r.Clouds, c)
+			continue
+		}
+
+		if events, ok := parseWeatherEvents(t); ok {
+			r.WeatherEvents = append(r.WeatherEvents, events...)
+			continue
+		}
+
+		r.Other = append(r.Other, t)
+	}
+	return r
+}
+
+// takeLocation consumes location words (OHD, VC, DSNT, ALQDS, compass
+// directions joined with AND) starting at tokens[start]. It returns the
+// location text and the index of the last token consumed.
+func takeLocation(tokens []string, start int) (string, int) {
+	var loc []string
+	j := start
+	for ; j < len(tokens); j++ {
+		t := tokens[j]
+		if t == "OHD" || t == "VC" || t == "DSNT" || t == "ALQDS" || t == "ALQS" || reDirection.MatchString(t) {
+			loc = append(loc, t)
+			continue
+		}
+		if t == "AND" && len(loc) > 0 && j+1 < len(tokens) && reDirection.MatchString(tokens[j+1]) {
+			loc = append(loc, t)
+			continue
+		}
+		break
+	}
+	return strings.Join(loc, " "), j - 1
+}
+
+// parseWeatherEvents splits begin/end groups such as RAB15E30SNB30 or
+// TSB0159E30 into individual events.
+func parseWeatherEvents(t string) ([]WeatherEvent, bool) {
+	var events []WeatherEvent
+	for t != "" {
+		m := reEvents.FindStringSubmatch(t)
+		if m == nil || !isWeatherCode(m[1]) {
+			return nil, false
+		}
+		for _, tm := range reEventTime.FindAllStringSubmatch(m[2], -1) {
+			ev := WeatherEvent{Weather: m[1], Began: tm[1] == "B"}
+			if tm[3] != "" {
+				ev.Hour = optionalInt(tm[2])
+				ev.Minute, _ = strconv.Atoi(tm[3])
+			} else {
+				ev.Minute, _ = strconv.Atoi(tm[2])
+			}
+			events = append(events, ev)
+		}
+		t = t[len(m[0]):]
+	}
+	return events, len(events) > 0
+}
+
+// isWeatherCode reports whether s is made only of two-letter weather
+// descriptors and phenomena, e.g. FZRA or TS.
+func isWeatherCode(s string) bool {
+	if len(s) == 0 || len(s)%2 != 0 {
+		return false
+	}
+	for j := 0; j < len(s); j += 2 {
+		c := s[j : j+2]
+		if decodeWxPhenomena(c) == "" && decodeWxDescriptor(c) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func signedTenths(sign, digits string) *float64 {
+	n, _ := strconv.Atoi(digits)
+	v := float64(n) / 10
+	if sign == "1" {
+		v = -v
+	}
+	return &v
+}
+
+func optionalInt(s string) *int {
+	if s == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// describeRemarks renders decoded remarks as one line per item, in units u.
+func describeRemarks(r *Remarks, u displayUnits) []string {
+	var out []string
+	add := func(format string, args ...any) {
+		out = append(out, fmt.Sprintf(format, args...))
+	}
+
+	switch r.StationType {
+	case "AO1":
+		add("Station type: Automated, without precipitation discriminator (AO1)")
+	case "AO2":
+		add("Station type: Automated, with precipitation discriminator (AO2)")
+	}
+	if r.SeaLevelPressure != nil {
+		add("Sea-level pressure: %s", u.pressure(*r.SeaLevelPressure, "hPa", true))
+	}
+	if r.SLPNotAvailable {
+		add("Sea-level pressure: not available")
+	}
+	if r.PreciseTempC != nil {
+		if r.PreciseDewC != nil {
+			add("Temp/Dew (precise): %s / %s", u.tempTenths(*r.PreciseTempC), u.tempTenths(*r.PreciseDewC))
+		} else {
+			add("Temp (precise): %s", u.tempTenths(*r.PreciseTempC))
+		}
+	}
+	if r.HourlyPrecipIn != nil {
+		add("Precipitation last hour: %s", describePrecipIn(*r.HourlyPrecipIn, u))
+	}
+	if r.Precip6hIn != nil {
+		add("Precipitation last 3/6 hours: %s", describePrecipIn(*r.Precip6hIn, u))
+	}
+	if r.Precip24hIn != nil {
+		add("Precipitation last 24 hours: %s", describePrecipIn(*r.Precip24hIn, u))
+	}
+	if r.MaxTemp6hC != nil {
+		add("6-hour maximum temperature: %s", u.tempTenths(*r.MaxTemp6hC))
+	}
+	if r.MinTemp6hC != nil {
+		add("6-hour minimum temperature: %s", u.tempTenths(*r.MinTemp6hC))
+	}
+	if r.MaxTemp24hC != nil && r.MinTemp24hC != nil {
+		add("24-hour max/min temperature: %s / %s", u.tempTenths(*r.MaxTemp24hC), u.tempTenths(*r.MinTemp24hC))
+	}
+	if p := r.PressureTendency; p != nil {
+		change := u.pressure(p.Change, "hPa", true)
+		if p.Change > 0 {
+			change = "+" + change
+		}
+		add("3-hour pressure tendency: %s (%s)", decodePressureTendency(p.Code), change)
+	}
+	switch r.PressureChange {
+	case "PRESRR":
+		add("Pressure rising rapidly")
+	case "PRESFR":
+		add("Pressure falling rapidly")
+	}
+	if pk := r.PeakWind; pk != nil {
+		add("Peak wind: %03d° at %s at %s", pk.Direction, u.speed(pk.Speed, "KT"), describeRemarkTime(pk.Hour, pk.Minute))
+	}
+	if ws := r.WindShift; ws != nil {
+		s := "Wind shift at " + describeRemarkTime(ws.Hour, ws.Minute)
+		if ws.Frontal {
+			s += " (frontal passage)"
+		}
+		add("%s", s)
+	}
+	for _, ev := range r.WeatherEvents {
+		verb := "ended"
+		if ev.Began {
+			verb = "began"
+		}
+		add("%s %s at %s", upperFirst(decodeWxToken(ev.Weather)), verb, describeRemarkTime(ev.Hour, ev.Minute))
+	}
+	for _, l := range r.Lightning {
+		add("%s", describeLightning(l))
+	}
+	if r.Virga != nil {
+		add("%s", withLocation("Virga", *r.Virga))
+	}
+	for _, c := range r.Clouds {
+		add("%s", withLocation(decodeRemarkCloud(c.Type), c))
+	}
+	for _, s := range r.SensorOutages {
+		add("%s (%s)", sensorOutages[s], s)
+	}
+	if r.Maintenance {
+		add("Station needs maintenance ($)")
+	}
+	if len(r.Other) > 0 {
+		add("Other: %s", strings.Join(r.Other, " "))
+	}
+	return out
+}
+
+func describePrecipIn(in float64, u displayUnits) string {
+	if in == 0 {
+		return "trace"
+	}
+	return u.precip(in)
+}
+
+// describeRemarkTime renders a remark time, which is either minutes past the
+// observation hour or a full hhmm.
+func describeRemarkTime(hour *int, minute int) string {
+	if hour == nil {
+		return fmt.Sprintf(":%02d past the hour", minute)
+	}
+	return fmt.Sprintf("%02d%02dZ", *hour, minute)
+}
+
+func describeLightning(l Lightning) string {
+	freq := map[string]string{
+		"OCNL": "Occasional ",
+		"FRQ":  "Frequent ",
+		"CONS": "Continuous ",
+	}[l.Frequency]
+	var types []string
+	for _, t := range l.Types {
+		types = append(types, map[string]string{
+			"IC": "in-cloud",
+			"CC": "cloud-to-cloud",
+			"CG": "cloud-to-ground",
+			"CA": "cloud-to-air",
+		}[t])
+	}
+	s := freq + "lightning"
+	if freq == "" {
+		s = "Lightning"
+	}
+	if len(types) > 0 {
+		s += " (" + strings.Join(types, ", ") + ")"
+	}
+	if l.Location != "" {
+		s += " " + describeLocation(l.Location)
+	}
+	return s
+}
+
+func withLocation(what string, l Located) string {
+	s := what
+	if l.Location != "" {
+		s += " " + describeLocation(l.Location)
+	}
+	if l.Movement != "" {
+		s += ", moving " + l.Movement
+	}
+	return s
+}
+
+func describeLocation(loc string) string {
+	var out []string
+	for _, w := range strings.Fields(loc) {
+		switch w {
+		case "OHD":
+			out = append(out, "overhead")
+		case "VC":
+			out = append(out, "in the vicinity")
+		case "DSNT":
+			out = append(out, "distant")
+		case "ALQDS", "ALQS":
+			out = append(out, "all quadrants")
+		case "AND":
+			out = append(out, "and")
+		default:
+			out = append(out, w)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+func decodeRemarkCloud(code string) string {
+	switch code {
+	case "CB":
+		return "Cumulonimbus"
+	case "CBMAM":
+		return "Cumulonimbus mammatus"
+	case "TCU":
+		return "Towering cumulus"
+	case "ACC":
+		return "Altocumulus castellanus"
+	default:
+		return code
+	}
+}
+
+func decodePressureTendency(code int) string {
+	switch code {
+	case 0:
+		return "increasing, then decreasing"
+	case 1:
+		return "increasing, then steady; or increasing, then increasing more slowly"
+	case 2:
+		return "increasing steadily or unsteadily"
+	case 3:
+		return "decreasing or steady, then increasing; or increasing, then increasing more rapidly"
+	case 4:
+		return "steady"
+	case 5:
+		return "decreasing, then increasing"
+	case 6:
+		return "decreasing, then steady; or decreasing, then decreasing more slowly"
+	case 7:
+		return "decreasing steadily or unsteadily"
+	case 8:
+		return "steady or increasing, then decreasing; or decreasing, then decreasing more rapidly"
+	default:
+		return "unknown"
+	}
+}
+
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
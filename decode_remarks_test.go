@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestParseRemarksPressureTendency(t *testing.T) {
+	tests := []struct {
+		group  string
+		code   int
+		change float64
+		text   string
+	}{
+		{"52032", 2, 3.2, "3-hour pressure tendency: increasing steadily or unsteadily (+3.2 hPa)"},
+		{"53004", 3, 0.4, "3-hour pressure tendency: decreasing or steady, then increasing; or increasing, then increasing more rapidly (+0.4 hPa)"},
+		{"54000", 4, 0, "3-hour pressure tendency: steady (0.0 hPa)"},
+		{"57015", 7, -1.5, "3-hour pressure tendency: decreasing steadily or unsteadily (-1.5 hPa)"},
+		{"58012", 8, -1.2, "3-hour pressure tendency: steady or increasing, then decreasing; or decreasing, then decreasing more rapidly (-1.2 hPa)"},
+	}
+	for _, tt := range tests {
+		r := parseRemarks("AO2 " + tt.group)
+		p := r.PressureTendency
+		if p == nil {
+			t.Errorf("%s: no pressure tendency", tt.group)
+			continue
+		}
+		if p.Code != tt.code || p.Change != tt.change {
+			t.Errorf("%s: code %d change %v, want %d %v", tt.group, p.Code, p.Change, tt.code, tt.change)
+		}
+		if lines := describeRemarks(r, displayUnits{}); len(lines) < 2 || lines[1] != tt.text {
+			t.Errorf("%s: text %q, want %q", tt.group, lines, tt.text)
+		}
+	}
+}
@@ -0,0 +1,592 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// decodedSchema identifies the layout written by --decode --format json.
+// Fields may be added within a version; renaming or removing one requires a
+// new version string.
+const decodedSchema = "metar-tool/decoded/v1"
+
+type decodedReport struct {
+	Schema      string                 `json:"schema"`
+	Raw         string                 `json:"raw"`
+	Station     string                 `json:"station,omitempty"`
+	DistanceNM  *float64               `json:"distance_nm,omitempty"` // from the area or --near search point
+	Bearing     *float64               `json:"bearing_deg,omitempty"` // true, from the search point
+	ReportType  string                 `json:"report_type,omitempty"`
+	Time        *decodedTime           `json:"time,omitempty"`
+	Stale       bool                   `json:"stale,omitempty"`
+	Modifier    string                 `json:"modifier,omitempty"`
+	Missing     bool                   `json:"nil,omitempty"`
+	Wind        *decodedWind           `json:"wind,omitempty"`
+	Visibility  *decodedVisibility     `json:"visibility,omitempty"`
+	CAVOK       bool                   `json:"cavok,omitempty"`
+	RVR         []decodedRVR           `json:"rvr,omitempty"`
+	Weather     []decodedWeather       `json:"weather,omitempty"`
+	Sky         []decodedSkyLayer      `json:"sky,omitempty"`
+	Ceiling     *decodedQuantity       `json:"ceiling,omitempty"`
+	Category    string                 `json:"flight_category,omitempty"`
+	CategoryAPI string                 `json:"flight_category_reported,omitempty"` // fltCat, only when it disagrees
+	Temp        *decodedQuantity       `json:"temperature,omitempty"`
+	Dewpoint    *decodedQuantity       `json:"dewpoint,omitempty"`
+	Altimeter   *decodedQuantity       `json:"altimeter,omitempty"`
+	NoSig       bool                   `json:"nosig,omitempty"`
+	Trends      []decodedForecastGroup `json:"trends,omitempty"`
+	Other       []string               `json:"other,omitempty"`
+	Remarks     string                 `json:"remarks,omitempty"`
+	RMK         *decodedRemarks        `json:"remarks_decoded,omitempty"`
+	StationInfo *decodedStationInfo    `json:"station_info,omitempty"`
+	Derived     *decodedDerived        `json:"derived,omitempty"`
+	RunwayWinds []decodedRunwayWind    `json:"runway_winds,omitempty"`
+}
+
+// decodedRunwayWind is one runwayWind; speeds are in knots.
+type decodedRunwayWind struct {
+	Runway        string           `json:"runway"`
+	HeadingDeg    float64          `json:"heading_deg"`
+	Headwind      decodedQuantity  `json:"headwind"`  // negative for a tailwind
+	Crosswind     decodedQuantity  `json:"crosswind"` // positive from the right
+	GustHeadwind  *decodedQuantity `json:"gust_headwind,omitempty"`
+	GustCrosswind *decodedQuantity `json:"gust_crosswind,omitempty"`
+	ExceedsLimit  bool             `json:"exceeds_limit,omitempty"`
+	Favored       bool             `json:"favored,omitempty"`
+}
+
+// decodedDerived holds the values computed by deriveValues, rounded to the
+// precision the inputs support.
+type decodedDerived struct {
+	RelativeHumidity *decodedQuantity `json:"relative_humidity,omitempty"`
+	DewpointSpread   *decodedQuantity `json:"dewpoint_spread,omitempty"`
+	CloudBase        *decodedQuantity `json:"cloud_base_estimate,omitempty"`
+	PressureAltitude *decodedQuantity `json:"pressure_altitude,omitempty"`
+	DensityAltitude  *decodedQuantity `json:"density_altitude,omitempty"`
+	HeatIndex        *decodedQuantity `json:"heat_index,omitempty"`
+	WindChill        *decodedQuantity `json:"wind_chill,omitempty"`
+}
+
+type decodedStationInfo struct {
+	Name      string          `json:"name,omitempty"`
+	State     string          `json:"state,omitempty"`
+	Country   string          `json:"country,omitempty"`
+	Latitude  float64         `json:"latitude"`
+	Longitude float64         `json:"longitude"`
+	Elevation decodedQuantity `json:"elevation"`
+	TimeZone  string          `json:"time_zone,omitempty"`
+	Variation *float64        `json:"magnetic_variation_deg,omitempty"` // east positive, from the WMM
+}
+
+// decodedQuantity is a number with its unit, e.g. {"value": 29.69, "unit": "inHg"}.
+type decodedQuantity struct {
+	Value float64 `json:"value"`
+	Unit  string  `json:"unit"`
+}
+
+type decodedTime struct {
+	Raw    string `json:"raw"`
+	Day    int    `json:"day"`
+	Hour   int    `json:"hour"`
+	Minute int    `json:"minute"`
+	UTC    string `json:"utc,omitempty"`   // RFC 3339, once the month and year are resolved
+	Local  string `json:"local,omitempty"` // RFC 3339 in the station's time zone, when known
+}
+
+type decodedWind struct {
+	Direction         *int              `json:"direction_deg"`                    // true; null when variable
+	DirectionMagnetic *int              `json:"direction_magnetic_deg,omitempty"` // when the station position is known
+	Variable          bool              `json:"variable"`
+	Calm              bool              `json:"calm"`
+	Speed             int               `json:"speed"`
+	Gust              *int              `json:"gust,omitempty"`
+	Unit              string            `json:"unit"`
+	Variation         *decodedVariation `json:"variation,omitempty"`
+	Text              string            `json:"text"`
+}
+
+type decodedVariation struct {
+	From int `json:"from_deg"`
+	To   int `json:"to_deg"`
+}
+
+type decodedVisibility struct {
+	Value     float64                   `json:"value"`
+	Unit      string                    `json:"unit"`
+	Qualifier string                    `json:"qualifier,omitempty"` // "greater_than" or "less_than"
+	Minimum   *decodedMinimumVisibility `json:"minimum,omitempty"`
+	Text      string                    `json:"text"`
+}
+
+type decodedMinimumVisibility struct {
+	Value     int    `json:"value"`
+	Unit      string `json:"unit"`
+	Direction string `json:"direction"`
+}
+
+type decodedRVR struct {
+	Runway   string           `json:"runway"`
+	Min      decodedRVRValue  `json:"value"`
+	Max      *decodedRVRValue `json:"variable_max,omitempty"`
+	Tendency string           `json:"tendency,omitempty"` // "increasing", "decreasing" or "no_change"
+	Text     string           `json:"text"`
+}
+
+type decodedRVRValue struct {
+	Value     int    `json:"value"`
+	Unit      string `json:"unit"`                // "ft" or "m"
+	Qualifier string `json:"qualifier,omitempty"` // "greater_than" or "less_than"
+}
+
+type decodedWeather struct {
+	Raw        string   `json:"raw"`
+	Intensity  string   `json:"intensity,omitempty"` // "light" or "heavy"
+	Vicinity   bool     `json:"vicinity,omitempty"`
+	Descriptor string   `json:"descriptor,omitempty"`
+	Phenomena  []string `json:"phenomena,omitempty"`
+	Text       string   `json:"text"`
+}
+
+type decodedRemarks struct {
+	StationType      string                   `json:"station_type,omitempty"`
+	SeaLevelPressure *decodedQuantity         `json:"sea_level_pressure,omitempty"`
+	Temp             *decodedQuantity         `json:"temperature,omitempty"`
+	Dewpoint         *decodedQuantity         `json:"dewpoint,omitempty"`
+	Precip1h         *decodedQuantity         `json:"precip_1h,omitempty"`
+	Precip6h         *decodedQuantity         `json:"precip_3h_6h,omitempty"`
+	Precip24h        *decodedQuantity         `json:"precip_24h,omitempty"`
+	MaxTemp6h        *decodedQuantity         `json:"max_temperature_6h,omitempty"`
+	MinTemp6h        *decodedQuantity         `json:"min_temperature_6h,omitempty"`
+	MaxTemp24h       *decodedQuantity         `json:"max_temperature_24h,omitempty"`
+	MinTemp24h       *decodedQuantity         `json:"min_temperature_24h,omitempty"`
+	PressureTendency *decodedPressureTendency `json:"pressure_tendency,omitempty"`
+	PressureChange   string                   `json:"pressure_change,omitempty"`
+	PeakWind         *decodedPeakWind         `json:"peak_wind,omitempty"`
+	WindShift        *decodedWindShift        `json:"wind_shift,omitempty"`
+	WeatherEvents    []decodedWeatherEvent    `json:"weather_events,omitempty"`
+	Lightning        []decodedLightning       `json:"lightning,omitempty"`
+	Virga            *decodedLocated          `json:"virga,omitempty"`
+	Clouds           []decodedLocated         `json:"clouds,omitempty"`
+	Maintenance      bool                     `json:"maintenance,omitempty"`
+	SensorOutages    []string                 `json:"sensor_outages,omitempty"`
+	Other            []string                 `json:"other,omitempty"`
+	Text             []string                 `json:"text"`
+}
+
+type decodedPressureTendency struct {
+	Code   int             `json:"code"`
+	Change decodedQuantity `json:"change"`
+	Text   string          `json:"text"`
+}
+
+type decodedPeakWind struct {
+	Direction int    `json:"direction_deg"`
+	Speed     int    `json:"speed"`
+	Unit      string `json:"unit"`
+	Hour      *int   `json:"hour,omitempty"`
+	Minute    int    `json:"minute"`
+}
+
+type decodedWindShift struct {
+	Hour    *int `json:"hour,omitempty"`
+	Minute  int  `json:"minute"`
+	Frontal bool `json:"frontal_passage"`
+}
+
+type decodedWeatherEvent struct {
+	Weather string `json:"weather"`
+	Event   string `json:"event"` // "began" or "ended"
+	Hour    *int   `json:"hour,omitempty"`
+	Minute  int    `json:"minute"`
+}
+
+type decodedLightning struct {
+	Frequency string   `json:"frequency,omitempty"`
+	Types     []string `json:"types,omitempty"`
+	Location  string   `json:"location,omitempty"`
+}
+
+type decodedLocated struct {
+	Type     string `json:"type"`
+	Location string `json:"location,omitempty"`
+	Movement string `json:"movement,omitempty"`
+}
+
+type decodedSkyLayer struct {
+	Cover string `json:"cover"`
+	Base  *int   `json:"base_ft,omitempty"`
+	Type  string `json:"type,omitempty"` // CB, TCU or "///"
+	Text  string `json:"text"`
+}
+
+func newDecodedReport(o *Observation) decodedReport {
+	r := decodedReport{
+		Schema:     decodedSchema,
+		Raw:        o.Raw,
+		Station:    o.Station,
+		ReportType: o.ReportType,
+		Modifier:   o.Modifier,
+		Missing:    o.Missing,
+		Remarks:    o.Remarks,
+		Stale:      o.Stale,
+	}
+
+	if o.Time != "" {
+		r.Time = &decodedTime{Raw: o.Time, Day: o.Day, Hour: o.Hour, Minute: o.Minute}
+		if !o.ObservedAt.IsZero() {
+			r.Time.UTC = o.ObservedAt.UTC().Format(time.RFC3339)
+			if o.Info != nil {
+				if local, ok := localTime(o.ObservedAt, o.Info.TimeZone); ok {
+					r.Time.Local = local.Format(time.RFC3339)
+				}
+			}
+		}
+	}
+
+	r.Wind = newDecodedWind(o.Wind)
+	if d, ok := magneticWindDirection(o); ok {
+		r.Wind.DirectionMagnetic = &d
+	}
+	r.Visibility = newDecodedVisibility(o.Visibility)
+	r.CAVOK = o.CAVOK
+	for _, rvr := range o.RVR {
+		r.RVR = append(r.RVR, newDecodedRVR(rvr))
+	}
+	r.Weather = newDecodedWeather(o.Weather)
+	r.Sky = newDecodedSky(o.Sky)
+	r.Ceiling = newDecodedCeiling(&o.Conditions)
+	if o.HasCategoryInputs() {
+		r.Category = o.FlightCategory()
+		if rc := strings.ToUpper(strings.TrimSpace(o.ReportedCategory)); rc != "" && rc != r.Category {
+			r.CategoryAPI = rc
+		}
+	}
+
+	if o.TempC != nil {
+		r.Temp = &decodedQuantity{Value: float64(*o.TempC), Unit: "C"}
+	}
+	if o.DewpointC != nil {
+		r.Dewpoint = &decodedQuantity{Value: float64(*o.DewpointC), Unit: "C"}
+	}
+	if a := o.Altimeter; a != nil {
+		r.Altimeter = &decodedQuantity{Value: a.Value, Unit: a.Unit}
+	}
+	r.NoSig = o.NoSigChange
+	for _, g := range o.Trends {
+		r.Trends = append(r.Trends, newDecodedForecastGroup(g))
+	}
+	r.Other = o.Other
+	if o.RMK != nil {
+		r.RMK = newDecodedRemarks(o.RMK)
+	}
+	if s := o.Info; s != nil {
+		r.StationInfo = &decodedStationInfo{
+			Name:      s.Site,
+			State:     s.State,
+			Country:   s.Country,
+			Latitude:  s.Lat,
+			Longitude: s.Lon,
+			Elevation: decodedQuantity{Value: s.Elev, Unit: "m"},
+			TimeZone:  s.TimeZone,
+		}
+		if v, ok := magneticVariation(o); ok {
+			v = math.Round(v*10)/10 + 0
+			r.StationInfo.Variation = &v
+		}
+		if o.Origin != nil {
+			p := latLon{Lat: s.Lat, Lon: s.Lon}
+			d := math.Round(distanceNM(*o.Origin, p)*10) / 10
+			b := math.Mod(math.Round(bearingDeg(*o.Origin, p)), 360)
+			r.DistanceNM, r.Bearing = &d, &b
+		}
+	}
+	for _, rw := range o.Runways {
+		kt := func(v float64) decodedQuantity {
+			return decodedQuantity{Value: math.Round(v) + 0, Unit: "kt"} // + 0 turns -0 into 0
+		}
+		d := decodedRunwayWind{
+			Runway:       rw.Runway,
+			HeadingDeg:   rw.Heading,
+			Headwind:     kt(rw.Headwind),
+			Crosswind:    kt(rw.Crosswind),
+			ExceedsLimit: rw.ExceedsLimit,
+			Favored:      rw.Favored,
+		}
+		if rw.GustHeadwind != nil {
+			h, c := kt(*rw.GustHeadwind), kt(*rw.GustCrosswind)
+			d.GustHeadwind, d.GustCrosswind = &h, &c
+		}
+		r.RunwayWinds = append(r.RunwayWinds, d)
+	}
+	if d := newDecodedDerived(deriveValues(o)); d != (decodedDerived{}) {
+		r.Derived = &d
+	}
+	return r
+}
+
+func newDecodedDerived(d derivedValues) decodedDerived {
+	// rounded keeps the given number of decimals; -2 rounds to hundreds.
+	rounded := func(v *float64, decimals int, unit string) *decodedQuantity {
+		if v == nil {
+			return nil
+		}
+		var r float64
+		if decimals >= 0 {
+			p := math.Pow(10, float64(decimals))
+			r = math.Round(*v*p) / p
+		} else {
+			p := math.Pow(10, float64(-decimals))
+			r = math.Round(*v/p) * p
+		}
+		return &decodedQuantity{Value: r, Unit: unit}
+	}
+	return decodedDerived{
+		RelativeHumidity: rounded(d.RelativeHumidity, 0, "%"),
+		DewpointSpread:   rounded(d.DewpointSpread, 1, "C"),
+		CloudBase:        rounded(d.CloudBase, -2, "ft"),
+		PressureAltitude: rounded(d.PressureAltitude, 0, "ft"),
+		DensityAltitude:  rounded(d.DensityAltitude, 0, "ft"),
+		HeatIndex:        rounded(d.HeatIndex, 1, "C"),
+		WindChill:        rounded(d.WindChill, 1, "C"),
+	}
+}
+
+func newDecodedWind(w *Wind) *decodedWind {
+	if w == nil {
+		return nil
+	}
+	dw := &decodedWind{
+		Variable: w.Variable,
+		Calm:     w.Calm(),
+		Speed:    w.Speed,
+		Gust:     w.Gust,
+		Unit:     strings.ToLower(w.Unit),
+		Text:     describeWind(w, displayUnits{}),
+	}
+	if !w.Variable {
+		dir := w.Direction
+		dw.Direction = &dir
+	}
+	if v := w.Variation; v != nil {
+		dw.Variation = &decodedVariation{From: v.From, To: v.To}
+	}
+	return dw
+}
+
+func newDecodedVisibility(v *Visibility) *decodedVisibility {
+	if v == nil {
+		return nil
+	}
+	dv := &decodedVisibility{Value: v.Value, Unit: v.Unit, Qualifier: qualifierName(v.Modifier), Text: describeVisibility(v, displayUnits{})}
+	if m := v.Minimum; m != nil {
+		dv.Minimum = &decodedMinimumVisibility{Value: m.Meters, Unit: "m", Direction: m.Direction}
+	}
+	return dv
+}
+
+func newDecodedRVR(r RunwayVisualRange) decodedRVR {
+	unit := strings.ToLower(r.Unit)
+	value := func(n int, mod string) decodedRVRValue {
+		return decodedRVRValue{Value: n, Unit: unit, Qualifier: qualifierName(mod)}
+	}
+	d := decodedRVR{Runway: r.Runway, Min: value(r.Min, r.MinModifier), Text: describeRVR(r, displayUnits{})}
+	if r.Max != nil {
+		hi := value(*r.Max, r.MaxModifier)
+		d.Max = &hi
+	}
+	switch r.Tendency {
+	case "U":
+		d.Tendency = "increasing"
+	case "D":
+		d.Tendency = "decreasing"
+	case "N":
+		d.Tendency = "no_change"
+	}
+	return d
+}
+
+// qualifierName maps the METAR P/M prefixes to their schema names.
+func qualifierName(mod string) string {
+	switch mod {
+	case "P":
+		return "greater_than"
+	case "M":
+		return "less_than"
+	default:
+		return ""
+	}
+}
+
+func newDecodedWeather(groups []WeatherGroup) []decodedWeather {
+	var out []decodedWeather
+	for _, g := range groups {
+		dw := decodedWeather{
+			Raw:        g.Raw,
+			Vicinity:   g.Vicinity,
+			Descriptor: g.Descriptor,
+			Phenomena:  g.Phenomena,
+			Text:       decodeWxToken(g.Raw),
+		}
+		switch g.Intensity {
+		case "-":
+			dw.Intensity = "light"
+		case "+":
+			dw.Intensity = "heavy"
+		}
+		out = append(out, dw)
+	}
+	return out
+}
+
+func newDecodedSky(layers []SkyLayer) []decodedSkyLayer {
+	var out []decodedSkyLayer
+	for _, l := range layers {
+		out = append(out, decodedSkyLayer{Cover: l.Cover, Base: l.Base, Type: l.Type, Text: describeSkyLayer(l, displayUnits{})})
+	}
+	return out
+}
+
+func newDecodedCeiling(c *Conditions) *decodedQuantity {
+	ceiling := c.Ceiling()
+	if ceiling == nil {
+		return nil
+	}
+	return &decodedQuantity{Value: float64(*ceiling), Unit: "ft"}
+}
+
+func newDecodedRemarks(rm *Remarks) *decodedRemarks {
+	d := &decodedRemarks{
+		StationType:      rm.StationType,
+		SeaLevelPressure: quantityPtr(rm.SeaLevelPressure, "hPa"),
+		Temp:             quantityPtr(rm.PreciseTempC, "C"),
+		Dewpoint:         quantityPtr(rm.PreciseDewC, "C"),
+		Precip1h:         quantityPtr(rm.HourlyPrecipIn, "in"),
+		Precip6h:         quantityPtr(rm.Precip6hIn, "in"),
+		Precip24h:        quantityPtr(rm.Precip24hIn, "in"),
+		MaxTemp6h:        quantityPtr(rm.MaxTemp6hC, "C"),
+		MinTemp6h:        quantityPtr(rm.MinTemp6hC, "C"),
+		MaxTemp24h:       quantityPtr(rm.MaxTemp24hC, "C"),
+		MinTemp24h:       quantityPtr(rm.MinTemp24hC, "C"),
+		PressureChange:   rm.PressureChange,
+		Maintenance:      rm.Maintenance,
+		SensorOutages:    rm.SensorOutages,
+		Other:            rm.Other,
+		Text:             describeRemarks(rm, displayUnits{}),
+	}
+	if p := rm.PressureTendency; p != nil {
+		d.PressureTendency = &decodedPressureTendency{
+			Code:   p.Code,
+			Change: decodedQuantity{Value: p.Change, Unit: "hPa"},
+			Text:   decodePressureTendency(p.Code),
+		}
+	}
+	if pk := rm.PeakWind; pk != nil {
+		d.PeakWind = &decodedPeakWind{Direction: pk.Direction, Speed: pk.Speed, Unit: "kt", Hour: pk.Hour, Minute: pk.Minute}
+	}
+	if ws := rm.WindShift; ws != nil {
+		d.WindShift = &decodedWindShift{Hour: ws.Hour, Minute: ws.Minute, Frontal: ws.Frontal}
+	}
+	for _, ev := range rm.WeatherEvents {
+		e := decodedWeatherEvent{Weather: ev.Weather, Event: "ended", Hour: ev.Hour, Minute: ev.Minute}
+		if ev.Began {
+			e.Event = "began"
+		}
+		d.WeatherEvents = append(d.WeatherEvents, e)
+	}
+	for _, l := range rm.Lightning {
+		d.Lightning = append(d.Lightning, decodedLightning{Frequency: l.Frequency, Types: l.Types, Location: l.Location})
+	}
+	if v := rm.Virga; v != nil {
+		d.Virga = &decodedLocated{Type: v.Type, Location: v.Location, Movement: v.Movement}
+	}
+	for _, c := range rm.Clouds {
+		d.Clouds = append(d.Clouds, decodedLocated{Type: c.Type, Location: c.Location, Movement: c.Movement})
+	}
+	return d
+}
+
+func quantityPtr(v *float64, unit string) *decodedQuantity {
+	if v == nil {
+		return nil
+	}
+	return &decodedQuantity{Value: *v, Unit: unit}
+}
+
+// printDecodedJSON writes observations as a JSON array of decodedReport.
+func printDecodedJSON(obs []*Observation, pretty bool) error {
+	reports := make([]decodedReport, 0, len(obs))
+	for _, o := range obs {
+		reports = append(reports, newDecodedReport(o))
+	}
+	return printJSON(reports, pretty)
+}
+
+func printJSON(v any, pretty bool) error {
+	var out []byte
+	var err error
+	if pretty {
+		out, err = json.MarshalIndent(v, "", "  ")
+	} else {
+		out, err = json.Marshal(v)
+	}
+	if err != nil {
+		return fmt.Errorf("encode JSON: %w", err)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// observationFromAW builds an Observation from an aviationweather.gov JSON
+// record, preferring the raw report when it is present.
+func observationFromAW(m awMetar) *Observation {
+	if strings.TrimSpace(m.RawOb) != "" {
+		if o, err := ParseMETAR(m.RawOb); err == nil && o.Station != "" {
+			o.ReportedCategory = m.FltCat
+			o.Info = stationInfoFromAW(m)
+			if !m.ObsTime.IsZero() {
+				o.ObservedAt = m.ObsTime.UTC()
+			}
+			return o
+		}
+	}
+
+	o := &Observation{Raw: strings.TrimSpace(m.RawOb), Station: strings.TrimSpace(m.ICAOId), ReportedCategory: m.FltCat, Info: stationInfoFromAW(m)}
+	if m.MetarType == "METAR" || m.MetarType == "SPECI" {
+		o.ReportType = m.MetarType
+	}
+	if t := m.ObsTime.Time; !t.IsZero() {
+		o.Day, o.Hour, o.Minute = t.Day(), t.Hour(), t.Minute()
+		o.Time = t.Format("021504Z")
+		o.ObservedAt = t.UTC()
+	}
+	if speed := m.WSpd.Int(); speed != nil {
+		w := &Wind{Speed: *speed, Unit: "KT", Gust: m.WGst.Int()}
+		if d := m.WDir.Int(); d != nil && *d >= 0 {
+			w.Direction = *d
+		} else {
+			w.Variable = true
+		}
+		o.Wind = w
+	}
+	if m.Visib.Valid() {
+		o.Visibility = &Visibility{Value: *m.Visib.Value, Unit: "SM"}
+		if m.Visib.Plus() {
+			o.Visibility.Modifier = "P"
+		}
+	}
+	for _, t := range strings.Fields(m.WxString) {
+		o.Weather = append(o.Weather, parseWeatherGroup(t))
+	}
+	for _, c := range m.Clouds {
+		o.Sky = append(o.Sky, skyLayerFromAW(c))
+	}
+	o.TempC = m.Temp.Int()
+	o.DewpointC = m.Dewp.Int()
+	if m.Altim.Valid() {
+		o.Altimeter = &Altimeter{Value: *m.Altim.Value, Unit: "hPa"}
+	}
+	return o
+}
@@ -0,0 +1,22 @@
+package main
+
+import "testing"
+
+func TestDecodedReportDistance(t *testing.T) {
+	o, err := ParseMETAR("METAR KTYS 151753Z 21010KT 10SM CLR 20/10 A3000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.Info = &stationInfo{Lat: 35.81, Lon: -83.99}
+	if r := newDecodedReport(o); r.DistanceNM != nil || r.Bearing != nil {
+		t.Errorf("distance %v, bearing %v without a search point", r.DistanceNM, r.Bearing)
+	}
+	o.Origin = &latLon{Lat: 35.81, Lon: -84.99} // 1° of longitude due west
+	r := newDecodedReport(o)
+	if r.DistanceNM == nil || r.Bearing == nil {
+		t.Fatal("no distance or bearing from the search point")
+	}
+	if *r.DistanceNM != 48.7 || *r.Bearing != 90 {
+		t.Errorf("distance %v NM, bearing %v, want 48.7 NM, 90", *r.DistanceNM, *r.Bearing)
+	}
+}
@@ -0,0 +1,335 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// awTAF is the subset of the aviationweather.gov TAF JSON we decode; the
+// forecast itself is re-parsed from rawTAF.
+type awTAF struct {
+	ICAOId string `json:"icaoId"`
+	RawTAF string `json:"rawTAF"`
+}
+
+// splitTAFs splits text holding one or more TAFs into individual reports. A
+// new report starts at a line beginning with "TAF" or after a blank line.
+func splitTAFs(s string) []string {
+	var reports []string
+	var cur []string
+	flush := func() {
+		if len(cur) > 0 {
+			reports = append(reports, strings.Join(cur, "\n"))
+			cur = nil
+		}
+	}
+	for _, ln := range strings.Split(s, "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln == "" {
+			flush()
+			continue
+		}
+		if strings.HasPrefix(ln, "TAF ") || ln == "TAF" {
+			flush()
+		}
+		cur = append(cur, ln)
+	}
+	flush()
+	return reports
+}
+
+// tafStation returns the station identifier of a raw TAF, or "" when it
+// has none.
+func tafStation(raw string) string {
+	for _, t := range strings.Fields(raw) {
+		switch t {
+		case "TAF", "AMD", "COR":
+			continue
+		}
+		return t
+	}
+	return ""
+}
+
+// parseTAFs parses each report in s. A report that does not parse is
+// skipped and its error joined into the one returned, so the TAFs that did
+// parse can still be shown.
+func parseTAFs(s string) ([]*TAF, error) {
+	var tafs []*TAF
+	var errs []error
+	n := 0
+	for _, r := range splitTAFs(s) {
+		// A lone "TAF" header line belongs to the report that follows.
+		if r == "TAF" {
+			continue
+		}
+		n++
+		taf, err := ParseTAF(r)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("TAF %d: %w", n, err))
+			continue
+		}
+		tafs = append(tafs, taf)
+	}
+	if n == 0 {
+		return nil, fmt.Errorf("no TAF content found")
+	}
+	return tafs, errors.Join(errs...)
+}
+
+func decodeRawTAFToHuman(raw string, u displayUnits) error {
+	tafs, err := parseTAFs(raw)
+	if len(tafs) == 0 {
+		return err
+	}
+	for i, t := range tafs {
+		if i > 0 {
+			fmt.Println()
+		}
+		printTAFHuman(t, u)
+	}
+	return err
+}
+
+func printTAFHuman(t *TAF, u displayUnits) {
+	fmt.Printf("Station: %s\n", t.Station)
+
+	report := "TAF"
+	switch {
+	case t.Amended && t.Corrected:
+		report += " (amended, corrected)"
+	case t.Amended:
+		report += " (amended)"
+	case t.Corrected:
+		report += " (corrected)"
+	}
+	fmt.Printf("Report: %s\n", report)
+
+	if t.IssueTime != "" {
+		fmt.Printf("Issued: %s (DDHHMMZ)\n", t.IssueTime)
+	}
+	if t.ValidFrom != nil {
+		fmt.Printf("Valid: %s to %s\n", t.ValidFrom, t.ValidTo)
+	}
+	if t.Cancelled {
+		fmt.Printf("Status: Cancelled\n")
+	}
+	if t.NoForecast {
+		fmt.Printf("Status: No forecast issued (NIL)\n")
+	}
+
+	for _, g := range t.Groups {
+		fmt.Printf("%s:\n", describeChangeGroup(g))
+		for _, ln := range describeForecastConditions(g, u) {
+			fmt.Printf("  %s\n", ln)
+		}
+	}
+
+	if t.Remarks != "" {
+		fmt.Printf("Remarks: %s\n", t.Remarks)
+	}
+	fmt.Printf("Raw: %s\n", t.Raw)
+}
+
+func describeChangeGroup(g ForecastGroup) string {
+	period := ""
+	if g.From != nil && g.To != nil {
+		period = fmt.Sprintf("%s to %s", g.From, g.To)
+	} else if g.From != nil {
+		period = g.From.String()
+	} else if len(g.TrendTimes) > 0 {
+		period = describeTrendTimes(g.TrendTimes)
+	}
+	sep := ""
+	if period != "" {
+		sep = ", "
+	}
+
+	switch g.Change {
+	case "":
+		return "Initially" + sep + period
+	case "FM":
+		return "From " + period
+	case "BECMG":
+		return "Becoming" + sep + period
+	case "TEMPO":
+		return "Temporarily" + sep + period
+	case "PROB":
+		if strings.Contains(g.Raw, "TEMPO") {
+			return fmt.Sprintf("%d%% chance temporarily%s%s", g.Probability, sep, period)
+		}
+		return fmt.Sprintf("%d%% chance%s%s", g.Probability, sep, period)
+	default:
+		return strings.TrimSpace(g.Change + " " + period)
+	}
+}
+
+// describeTrendTimes renders METAR trend time groups, e.g. FM1030 TL1100 as
+// "from 1030Z until 1100Z".
+func describeTrendTimes(times []string) string {
+	var parts []string
+	for _, t := range times {
+		word := map[string]string{"FM": "from", "TL": "until", "AT": "at"}[t[:2]]
+		parts = append(parts, fmt.Sprintf("%s %sZ", word, t[2:]))
+	}
+	return strings.Join(parts, " ")
+}
+
+func describeForecastConditions(g ForecastGroup, u displayUnits) []string {
+	var out []string
+	if g.Wind != nil {
+		out = append(out, "Wind: "+describeWind(g.Wind, u))
+		if v := g.Wind.Variation; v != nil {
+			out = append(out, fmt.Sprintf("Wind variation: %03dV%03d", v.From, v.To))
+		}
+	}
+	if g.Visibility != nil {
+		out = append(out, "Visibility: "+describeVisibility(g.Visibility, u))
+	}
+	if g.CAVOK {
+		out = append(out, "Visibility: "+describeCAVOK(u))
+	}
+	if len(g.Weather) > 0 {
+		var parts []string
+		for _, w := range g.Weather {
+			parts = append(parts, decodeWxToken(w.Raw))
+		}
+		out = append(out, "Weather: "+strings.Join(parts, ", "))
+	}
+	if g.NoSigWeather {
+		out = append(out, "Weather: No significant weather")
+	}
+	if len(g.Sky) > 0 {
+		var parts []string
+		for _, l := range g.Sky {
+			parts = append(parts, describeSkyLayer(l, u))
+		}
+		out = append(out, "Sky: "+strings.Join(parts, ", "))
+	}
+	if ws := g.WindShear; ws != nil {
+		out = append(out, fmt.Sprintf("Wind shear: %s at %s AGL", describeWind(&ws.Wind, u), u.height(ws.Height, "ft")))
+	}
+	if len(g.Other) > 0 {
+		out = append(out, "Other: "+strings.Join(g.Other, " "))
+	}
+	return out
+}
+
+// decodedTAFSchema identifies the TAF layout written by --decode --format
+// json. The same versioning rules as decodedSchema apply.
+const decodedTAFSchema = "metar-tool/decoded-taf/v1"
+
+type decodedTAF struct {
+	Schema     string                 `json:"schema"`
+	Raw        string                 `json:"raw"`
+	Station    string                 `json:"station"`
+	Amended    bool                   `json:"amended"`
+	Corrected  bool                   `json:"corrected"`
+	Issued     *decodedTime           `json:"issued,omitempty"`
+	ValidFrom  *decodedTAFTime        `json:"valid_from,omitempty"` // nil for a NIL or cancelled TAF without one
+	ValidTo    *decodedTAFTime        `json:"valid_to,omitempty"`
+	Cancelled  bool                   `json:"cancelled,omitempty"`
+	NoForecast bool                   `json:"nil,omitempty"`
+	Groups     []decodedForecastGroup `json:"groups"`
+	Remarks    string                 `json:"remarks,omitempty"`
+}
+
+type decodedTAFTime struct {
+	Day    int `json:"day"`
+	Hour   int `json:"hour"`
+	Minute int `json:"minute"`
+}
+
+type decodedForecastGroup struct {
+	Change       string             `json:"change"` // "base", FM, BECMG, TEMPO or PROB
+	Probability  int                `json:"probability,omitempty"`
+	Tempo        bool               `json:"tempo,omitempty"` // PROB30 TEMPO
+	From         *decodedTAFTime    `json:"from,omitempty"`
+	To           *decodedTAFTime    `json:"to,omitempty"`
+	TrendTimes   []string           `json:"trend_times,omitempty"` // METAR trends: FMhhmm, TLhhmm, AThhmm
+	Wind         *decodedWind       `json:"wind,omitempty"`
+	Visibility   *decodedVisibility `json:"visibility,omitempty"`
+	CAVOK        bool               `json:"cavok,omitempty"`
+	Weather      []decodedWeather   `json:"weather,omitempty"`
+	NoSigWeather bool               `json:"no_significant_weather,omitempty"`
+	Sky          []decodedSkyLayer  `json:"sky,omitempty"`
+	Ceiling      *decodedQuantity   `json:"ceiling,omitempty"`
+	WindShear    *decodedWindShear  `json:"wind_shear,omitempty"`
+	Other        []string           `json:"other,omitempty"`
+	Raw          string             `json:"raw"`
+}
+
+type decodedWindShear struct {
+	Height decodedQuantity `json:"height"`
+	Wind   *decodedWind    `json:"wind"`
+}
+
+func newDecodedTAFTime(t *TAFTime) *decodedTAFTime {
+	if t == nil {
+		return nil
+	}
+	return &decodedTAFTime{Day: t.Day, Hour: t.Hour, Minute: t.Minute}
+}
+
+func newDecodedTAF(t *TAF) decodedTAF {
+	d := decodedTAF{
+		Schema:     decodedTAFSchema,
+		Raw:        t.Raw,
+		Station:    t.Station,
+		Amended:    t.Amended,
+		Corrected:  t.Corrected,
+		ValidFrom:  newDecodedTAFTime(t.ValidFrom),
+		ValidTo:    newDecodedTAFTime(t.ValidTo),
+		Cancelled:  t.Cancelled,
+		NoForecast: t.NoForecast,
+		Groups:     []decodedForecastGroup{},
+		Remarks:    t.Remarks,
+	}
+	if t.Issued != nil {
+		d.Issued = &decodedTime{Raw: t.IssueTime, Day: t.Issued.Day, Hour: t.Issued.Hour, Minute: t.Issued.Minute}
+	}
+	for _, g := range t.Groups {
+		d.Groups = append(d.Groups, newDecodedForecastGroup(g))
+	}
+	return d
+}
+
+func newDecodedForecastGroup(g ForecastGroup) decodedForecastGroup {
+	dg := decodedForecastGroup{
+		Change:       g.Change,
+		Probability:  g.Probability,
+		Tempo:        g.Change == "PROB" && strings.Contains(g.Raw, "TEMPO"),
+		From:         newDecodedTAFTime(g.From),
+		To:           newDecodedTAFTime(g.To),
+		TrendTimes:   g.TrendTimes,
+		Wind:         newDecodedWind(g.Wind),
+		Visibility:   newDecodedVisibility(g.Visibility),
+		CAVOK:        g.CAVOK,
+		Weather:      newDecodedWeather(g.Weather),
+		NoSigWeather: g.NoSigWeather,
+		Sky:          newDecodedSky(g.Sky),
+		Ceiling:      newDecodedCeiling(&g.Conditions),
+		Other:        g.Other,
+		Raw:          g.Raw,
+	}
+	if dg.Change == "" {
+		dg.Change = "base"
+	}
+	if ws := g.WindShear; ws != nil {
+		dg.WindShear = &decodedWindShear{
+			Height: decodedQuantity{Value: float64(ws.Height), Unit: "ft"},
+			Wind:   newDecodedWind(&ws.Wind),
+		}
+	}
+	return dg
+}
+
+// printDecodedTAFJSON writes TAFs as a JSON array of decodedTAF.
+func printDecodedTAFJSON(tafs []*TAF, pretty bool) error {
+	out := make([]decodedTAF, 0, len(tafs))
+	for _, t := range tafs {
+		out = append(out, newDecodedTAF(t))
+	}
+	return printJSON(out, pretty)
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// Derived values follow the formulas of the NWS weather calculators:
+// Magnus vapour pressure for humidity, the altimeter-setting form of the
+// standard atmosphere for pressure altitude, virtual temperature for density
+// altitude, the Rothfusz regression for the heat index and the 2001 wind
+// chill index.
+
+// cloudBaseFtPerC is the rise, in feet, per degree Celsius of temperature /
+// dewpoint spread for a convective cloud base (2.5 °C per 1000 ft).
+const cloudBaseFtPerC = 400
+
+// derivedValues are quantities computed from a report. Each is nil when its
+// inputs are missing or, for the heat index and wind chill, when the index
+// does not apply.
+type derivedValues struct {
+	RelativeHumidity *float64 // percent
+	DewpointSpread   *float64 // °C
+	PressureAltitude *float64 // ft, needs the station elevation
+	DensityAltitude  *float64 // ft, needs the station elevation
+	HeatIndex        *float64 // °C, at 80 °F and above
+	WindChill        *float64 // °C, at 50 °F and below with wind above 3 mph
+	CloudBase        *float64 // ft AGL
+}
+
+// deriveValues computes what the report supports. Temperatures come from
+// the RMK T group when present, for the tenths; the elevation comes from the
+// station metadata.
+func deriveValues(o *Observation) derivedValues {
+	var d derivedValues
+	temp, dew := observedTempDew(o)
+
+	if temp != nil && dew != nil {
+		rh := 100 * vaporPressure(*dew) / vaporPressure(*temp)
+		spread := *temp - *dew
+		base := math.Max(0, spread) * cloudBaseFtPerC
+		d.RelativeHumidity, d.DewpointSpread, d.CloudBase = &rh, &spread, &base
+	}
+
+	if o.Altimeter != nil && o.Info != nil {
+		altIn := o.Altimeter.Value
+		if o.Altimeter.Unit == "hPa" {
+			altIn /= hPaPerInHg
+		}
+		elevFt := o.Info.Elev / metersPerFoot
+		pa := elevFt + 145366.45*(1-math.Pow(altIn/29.92126, 0.190284))
+		d.PressureAltitude = &pa
+
+		if temp != nil {
+			da := densityAltitude(altIn, o.Info.Elev, *temp, dew)
+			d.DensityAltitude = &da
+		}
+	}
+
+	if temp != nil && d.RelativeHumidity != nil {
+		if hi, ok := heatIndexF(celsiusToFahrenheit(*temp), *d.RelativeHumidity); ok {
+			c := fahrenheitToCelsius(hi)
+			d.HeatIndex = &c
+		}
+	}
+
+	if temp != nil && o.Wind != nil && !o.Wind.Calm() {
+		mph := float64(o.Wind.Speed) * metersPerSecond[o.Wind.Unit] / metersPerSecond["mph"]
+		if wc, ok := windChillF(celsiusToFahrenheit(*temp), mph); ok {
+			c := fahrenheitToCelsius(wc)
+			d.WindChill = &c
+		}
+	}
+	return d
+}
+
+// observedTempDew returns the temperature and dewpoint in °C, preferring the
+// tenths of the RMK T group.
+func observedTempDew(o *Observation) (temp, dew *float64) {
+	if o.RMK != nil {
+		temp, dew = o.RMK.PreciseTempC, o.RMK.PreciseDewC
+	}
+	if temp == nil && o.TempC != nil {
+		t := float64(*o.TempC)
+		temp = &t
+	}
+	if dew == nil && o.DewpointC != nil {
+		t := float64(*o.DewpointC)
+		dew = &t
+	}
+	return temp, dew
+}
+
+// vaporPressure is the saturation vapour pressure in hPa at c °C.
+func vaporPressure(c float64) float64 {
+	return 6.112 * math.Exp(17.62*c/(243.12+c))
+}
+
+// densityAltitude is the density altitude in feet for an altimeter setting
+// in inHg at a station elevMeters high. Without a dewpoint the air is taken
+// as dry.
+func densityAltitude(altIn, elevMeters, tempC float64, dewC *float64) float64 {
+	stationHPa := altIn * hPaPerInHg * math.Pow((288-0.0065*elevMeters)/288, 5.2561)
+	tv := tempC + 273.15
+	if dewC != nil {
+		e := vaporPressure(*dewC)
+		tv /= 1 - (e/stationHPa)*(1-0.622)
+	}
+	tvRankine := tv * 9 / 5
+	return 145366 * (1 - math.Pow(17.326*(stationHPa/hPaPerInHg)/tvRankine, 0.235))
+}
+
+// heatIndexF is the NWS heat index for a temperature in °F and relative
+// humidity in percent. It does not apply below 80 °F.
+func heatIndexF(t, rh float64) (float64, bool) {
+	if t < 80 {
+		return 0, false
+	}
+	hi := 0.5 * (t + 61 + (t-68)*1.2 + rh*0.094)
+	if (hi+t)/2 < 80 {
+		return hi, true
+	}
+	hi = -42.379 + 2.04901523*t + 10.14333127*rh - 0.22475541*t*rh -
+		0.00683783*t*t - 0.05481717*rh*rh + 0.00122874*t*t*rh +
+		0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+	switch {
+	case rh < 13 && t <= 112:
+		hi -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+	case rh > 85 && t <= 87:
+		hi += (rh - 85) / 10 * (87 - t) / 5
+	}
+	return hi, true
+}
+
+// windChillF is the NWS wind chill for a temperature in °F and wind in mph.
+// It applies at 50 °F and below with more than 3 mph of wind.
+func windChillF(t, mph float64) (float64, bool) {
+	if t > 50 || mph <= 3 {
+		return 0, false
+	}
+	v := math.Pow(mph, 0.16)
+	return 35.74 + 0.6215*t - 35.75*v + 0.4275*t*v, true
+}
+
+func fahrenheitToCelsius(f float64) float64 {
+	return (f - 32) * 5 / 9
+}
+
+// printDerived prints the "Derived:" section of decoded text, if the report
+// supports any derived value.
+func printDerived(o *Observation, u displayUnits) {
+	lines := describeDerived(deriveValues(o), u)
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Println("Derived:")
+	for _, ln := range lines {
+		fmt.Printf("  %s\n", ln)
+	}
+}
+
+// describeDerived renders the derived values as "Label: value" lines in
+// units u.
+func describeDerived(d derivedValues, u displayUnits) []string {
+	var out []string
+	add := func(format string, args ...any) {
+		out = append(out, fmt.Sprintf(format, args...))
+	}
+	feet := func(v float64) string {
+		return u.height(int(math.Round(v)), "ft")
+	}
+	if d.RelativeHumidity != nil {
+		add("Relative humidity: %.0f%%", *d.RelativeHumidity)
+	}
+	if d.DewpointSpread != nil {
+		add("Temp/dewpoint spread: %s", u.tempDiff(*d.DewpointSpread))
+	}
+	if d.CloudBase != nil {
+		add("Estimated cloud base: %s AGL", u.height(int(math.Round(*d.CloudBase/100)*100), "ft"))
+	}
+	if d.PressureAltitude != nil {
+		add("Pressure altitude: %s", feet(*d.PressureAltitude))
+	}
+	if d.DensityAltitude != nil {
+		add("Density altitude: %s", feet(*d.DensityAltitude))
+	}
+	if d.HeatIndex != nil {
+		add("Heat index: %s", u.tempTenths(*d.HeatIndex))
+	}
+	if d.WindChill != nil {
+		add("Wind chill: %s", u.tempTenths(*d.WindChill))
+	}
+	return out
+}
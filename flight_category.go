@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Flight categories as defined by the FAA for ceiling and visibility.
+const (
+	catVFR  = "VFR"
+	catMVFR = "MVFR"
+	catIFR  = "IFR"
+	catLIFR = "LIFR"
+)
+
+// flightCategory returns the FAA flight category for a ceiling in feet AGL
+// and a visibility in statute miles. A nil ceiling means no ceiling; a nil
+// visibility means it was not reported and only the ceiling is considered.
+func flightCategory(ceilingFt *int, visSM *float64) string {
+	cat := catVFR
+	worse := func(c string) {
+		if categoryRank(c) > categoryRank(cat) {
+			cat = c
+		}
+	}
+
+	if ceilingFt != nil {
+		switch c := *ceilingFt; {
+		case c < 500:
+			worse(catLIFR)
+		case c < 1000:
+			worse(catIFR)
+		case c <= 3000:
+			worse(catMVFR)
+		}
+	}
+	if visSM != nil {
+		switch v := *visSM; {
+		case v < 1:
+			worse(catLIFR)
+		case v < 3:
+			worse(catIFR)
+		case v <= 5:
+			worse(catMVFR)
+		}
+	}
+	return cat
+}
+
+// categoryRank orders categories from best (VFR) to worst (LIFR).
+func categoryRank(cat string) int {
+	switch cat {
+	case catVFR:
+		return 0
+	case catMVFR:
+		return 1
+	case catIFR:
+		return 2
+	case catLIFR:
+		return 3
+	default:
+		return -1
+	}
+}
+
+// FlightCategory returns the flight category for these conditions.
+func (c *Conditions) FlightCategory() string {
+	var vis *float64
+	if c.Visibility != nil {
+		sm := c.Visibility.StatuteMiles()
+		vis = &sm
+	} else if c.CAVOK {
+		sm := 10000 / metersPerStatuteMile
+		vis = &sm
+	}
+	return flightCategory(c.Ceiling(), vis)
+}
+
+// HasCategoryInputs reports whether enough was reported to compute a flight
+// category: a visibility (or CAVOK) or at least one sky group.
+func (c *Conditions) HasCategoryInputs() bool {
+	return c.Visibility != nil || c.CAVOK || len(c.Sky) > 0
+}
+
+// describeFlightCategory renders the computed category for the "Flight
+// category:" line, noting when the data source disagrees with it.
+func describeFlightCategory(o *Observation) string {
+	cat := o.FlightCategory()
+	reported := strings.ToUpper(strings.TrimSpace(o.ReportedCategory))
+	if reported != "" && reported != cat {
+		return fmt.Sprintf("%s (aviationweather.gov reports %s)", cat, reported)
+	}
+	return cat
+}
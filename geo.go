@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// earthRadiusNM is the mean Earth radius in nautical miles.
+const earthRadiusNM = 3440.065
+
+// latLon is a position in decimal degrees, north and east positive.
+type latLon struct {
+	Lat float64
+	Lon float64
+}
+
+// parseLatLon parses "35.818,-83.986".
+func parseLatLon(s string) (latLon, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return latLon{}, fmt.Errorf("expected lat,lon, got %q", s)
+	}
+	lat, err1 := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	lon, err2 := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err1 != nil || err2 != nil {
+		return latLon{}, fmt.Errorf("expected decimal degrees lat,lon, got %q", s)
+	}
+	p := latLon{Lat: lat, Lon: lon}
+	if !p.valid() {
+		return latLon{}, fmt.Errorf("position %q is out of range", s)
+	}
+	return p, nil
+}
+
+func (p latLon) valid() bool {
+	return p.Lat >= -90 && p.Lat <= 90 && p.Lon >= -180 && p.Lon <= 180
+}
+
+func (p latLon) String() string {
+	return fmt.Sprintf("%.4f,%.4f", p.Lat, p.Lon)
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+// distanceNM is the great-circle (haversine) distance between a and b.
+func distanceNM(a, b latLon) float64 {
+	dLat := radians(b.Lat - a.Lat)
+	dLon := radians(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(radians(a.Lat))*math.Cos(radians(b.Lat))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusNM * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// bearingDeg is the initial true bearing from a to b, 0-360.
+func bearingDeg(a, b latLon) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLon := radians(b.Lon - a.Lon)
+	y := math.Sin(dLon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dLon)
+	return math.Mod(degrees(math.Atan2(y, x))+360, 360)
+}
+
+// formatBearing renders a bearing as three whole degrees, e.g. "045°".
+func formatBearing(deg float64) string {
+	return fmt.Sprintf("%03.0f°", math.Mod(math.Round(deg), 360))
+}
+
+// bbox is a latitude/longitude bounding box. It does not cross the
+// antimeridian.
+type bbox struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// parseBBox parses "minLat,minLon,maxLat,maxLon", the order the
+// aviationweather.gov bbox parameter uses.
+func parseBBox(s string) (bbox, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return bbox{}, fmt.Errorf("expected minLat,minLon,maxLat,maxLon, got %q", s)
+	}
+	var n [4]float64
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return bbox{}, fmt.Errorf("expected decimal degrees minLat,minLon,maxLat,maxLon, got %q", s)
+		}
+		n[i] = v
+	}
+	b := bbox{MinLat: n[0], MinLon: n[1], MaxLat: n[2], MaxLon: n[3]}
+	if !(latLon{b.MinLat, b.MinLon}).valid() || !(latLon{b.MaxLat, b.MaxLon}).valid() || b.MinLat >= b.MaxLat || b.MinLon >= b.MaxLon {
+		return bbox{}, fmt.Errorf("bounding box %q is empty or out of range", s)
+	}
+	return b, nil
+}
+
+// bboxAround returns a box enclosing the circle of radius nm around c,
+// clamped to valid coordinates.
+func bboxAround(c latLon, nm float64) bbox {
+	dLat := nm / 60
+	dLon := 180.0
+	if cos := math.Cos(radians(c.Lat)); cos > 1e-6 {
+		dLon = math.Min(180, nm/(60*cos))
+	}
+	return bbox{
+		MinLat: math.Max(-90, c.Lat-dLat),
+		MinLon: math.Max(-180, c.Lon-dLon),
+		MaxLat: math.Min(90, c.Lat+dLat),
+		MaxLon: math.Min(180, c.Lon+dLon),
+	}
+}
+
+func (b bbox) center() latLon {
+	return latLon{Lat: (b.MinLat + b.MaxLat) / 2, Lon: (b.MinLon + b.MaxLon) / 2}
+}
+
+// query renders the box for the aviationweather.gov bbox parameter.
+func (b bbox) query() string {
+	return fmt.Sprintf("%.4f,%.4f,%.4f,%.4f", b.MinLat, b.MinLon, b.MaxLat, b.MaxLon)
+}
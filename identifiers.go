@@ -0,0 +1,86 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// airportIDsCSV maps ICAO identifiers to the IATA and FAA codes printed on
+// tickets and charts. It covers the larger airports; other US identifiers
+// fall back to the K prefix.
+//
+//go:embed airport_ids.csv
+var airportIDsCSV string
+
+// airportID is one row of the bundled identifier table.
+type airportID struct {
+	ICAO, IATA, FAA string
+	Name            string
+	State, Country  string
+	TimeZone        string // IANA
+}
+
+func (a airportID) place() string {
+	return stationPlace(stationInfo{Site: a.Name, State: a.State, Country: a.Country})
+}
+
+var airportIDs = sync.OnceValue(func() []airportID {
+	r := csv.NewReader(strings.NewReader(airportIDsCSV))
+	r.Comment = '#'
+	rows, err := r.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("airport_ids.csv: %v", err))
+	}
+	var out []airportID
+	for _, row := range rows[1:] { // header
+		out = append(out, airportID{ICAO: row[0], IATA: row[1], FAA: row[2], Name: row[3], State: row[4], Country: row[5], TimeZone: row[6]})
+	}
+	return out
+})
+
+// resolveStation turns an upper-cased identifier into the ICAO code the
+// aviationweather.gov API expects. Four-character identifiers are taken as
+// ICAO already. Three-character IATA codes and FAA location identifiers
+// (including local IDs such as 1A5) are looked up in the bundled table; a
+// code that names different airports as IATA and as FAA is an error listing
+// the candidates. A three-letter code the table does not know is assumed to
+// be a contiguous US FAA identifier and gets the K prefix, with a warning;
+// any other unknown code is returned as given, for validStation to judge.
+func resolveStation(id string) (string, error) {
+	if len(id) != 3 {
+		return id, nil
+	}
+	var matches []airportID
+	for _, a := range airportIDs() {
+		if a.IATA == id || a.FAA == id {
+			matches = append(matches, a)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		if strings.IndexFunc(id, func(r rune) bool { return r < 'A' || r > 'Z' }) >= 0 {
+			return id, nil
+		}
+		icao := "K" + id
+		fmt.Fprintf(os.Stderr, "WARNING: %s is not in the identifier table; assuming %s\n", id, icao)
+		return icao, nil
+	case 1:
+		return matches[0].ICAO, nil
+	}
+	var candidates []string
+	for _, a := range matches {
+		var kinds []string
+		if a.IATA == id {
+			kinds = append(kinds, "IATA")
+		}
+		if a.FAA == id {
+			kinds = append(kinds, "FAA")
+		}
+		candidates = append(candidates, fmt.Sprintf("%s (%s code for %s)", a.ICAO, strings.Join(kinds, "/"), a.place()))
+	}
+	return "", fmt.Errorf("ambiguous station identifier %s: %s; use the ICAO code", id, strings.Join(candidates, " or "))
+}
@@ -0,0 +1,21 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNormalizeStations(t *testing.T) {
+	got, err := normalizeStations([]string{"tys", "KTYS", "1a5", "anc", "yyz"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"KTYS", "K1A5", "PANC", "CYYZ"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("normalizeStations = %q, want %q", got, want)
+	}
+	for _, id := range []string{"K$$", "9Z9", "hnd"} {
+		if got, err := normalizeStations([]string{id}); err == nil {
+			t.Errorf("normalizeStations(%q) = %q, want an error", id, got)
+		}
+	}
+}
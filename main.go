@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"slices"
 	"strings"
 	"time"
 )
@@ -13,7 +14,17 @@ var Version = ""
 
 type options struct {
 	forecast  string
-	obs       string
+	obs       stationList
+	stations  string
+	hours     int
+	bbox      string
+	radius    float64
+	center    string
+	near      string
+	count     int
+	taf       string
+	info      stationList
+	timeline  bool
 	obsJSON   bool
 	pretty    bool
 	timeout   time.Duration
@@ -21,29 +32,102 @@ type options struct {
 	output    string
 	verbose   bool
 	decode    bool
+	format    string
+	units     string
+	enrich    bool
+	refDate   string
+
+	staleAfter time.Duration
+	failStale  bool
+	derived    bool
+	runways    bool
+	xwindLimit float64
+	check      bool
+	checkHours int
+	configFile string
+
+	// Defaults from the environment or the config file; see settings.
+	station string
+	wfo     string
+	home    *latLon
+
+	command string // the subcommand, or "" for the flag forms
 }
 
 func main() {
-	var opt options
+	if len(os.Args) > 1 && !strings.HasPrefix(os.Args[1], "-") {
+		runCommand(os.Args[1], os.Args[2:])
+		return
+	}
 
+	var opt options
 	showVersion := flag.Bool("version", false, "Print version and exit")
-
-	flag.StringVar(&opt.forecast, "forecast", "", `Forecast provider. Supported: "nws"`)
-	flag.StringVar(&opt.obs, "obs", "", "Fetch current raw METAR observation for a station (e.g. KRDU)")
-	flag.BoolVar(&opt.obsJSON, "json", false, "For --obs: output JSON instead of raw METAR text")
-	flag.BoolVar(&opt.pretty, "pretty", false, "For --json: pretty-print JSON")
-	flag.DurationVar(&opt.timeout, "timeout", 10*time.Second, "HTTP timeout (e.g. 5s, 10s)")
-	flag.StringVar(&opt.userAgent, "user-agent", "metar-tool/0.1 (contact: you@example.com)", "User-Agent to send to APIs")
-	flag.StringVar(&opt.output, "output", "", "Write normal output to this file (errors still go to stderr)")
-	flag.BoolVar(&opt.verbose, "verbose", false, "Verbose logging to stderr")
-	flag.BoolVar(&opt.decode, "decode", false, "Decode piped METAR/JSON from stdin into human-readable format")
-
+	addFlags(flag.CommandLine, &opt)
 	flag.Parse()
 
 	if *showVersion {
 		fmt.Printf("%s %s\n", "metar-tool", Version)
 		return
 	}
+	run(opt, visitedFlags(flag.CommandLine), flag.Args())
+}
+
+// addFlags defines every option flag on fs. The flag forms use them all;
+// each command copies the ones it takes (see commandFlagSet).
+func addFlags(fs *flag.FlagSet, opt *options) {
+	fs.StringVar(&opt.forecast, "forecast", "", `Forecast provider. Supported: "nws"`)
+	fs.Var(&opt.obs, "obs", "Fetch current raw METAR observations for one or more stations (ICAO or IATA/FAA codes, e.g. KRDU or TYS,RDU,CLT, or @group from the config file; repeatable)")
+	fs.StringVar(&opt.stations, "stations-file", "", "For --obs: read station identifiers from this file (one or more per line, # comments)")
+	fs.StringVar(&opt.taf, "taf", "", "Fetch the current TAF for a station (e.g. KTYS or TYS)")
+	fs.Var(&opt.info, "station-info", "Show name, location, elevation and time zone for one or more stations (e.g. KTYS or KTYS,KRDU)")
+	fs.IntVar(&opt.hours, "hours", 0, "For --obs: every METAR and SPECI from the last N hours, oldest first (default: latest only)")
+	fs.StringVar(&opt.bbox, "bbox", "", "Current observations for every station in a bounding box: minLat,minLon,maxLat,maxLon")
+	fs.Float64Var(&opt.radius, "radius", 0, "With --center: current observations for every station within this many nautical miles")
+	fs.StringVar(&opt.center, "center", "", "For --radius: the search center as lat,lon (e.g. 35.82,-83.99)")
+	fs.StringVar(&opt.near, "near", "", `Observations from the stations nearest to lat,lon, or "home" for the home location ($METAR_TOOL_HOME or the config file)`)
+	fs.IntVar(&opt.count, "count", 3, "For --near: number of stations")
+	fs.BoolVar(&opt.timeline, "timeline", false, "For --taf and --decode of a TAF: print an hour-by-hour timeline with flight categories")
+	fs.BoolVar(&opt.obsJSON, "json", false, "For --obs and --taf: output JSON instead of raw text")
+	fs.BoolVar(&opt.pretty, "pretty", false, "For --json and --format json: pretty-print JSON")
+	fs.DurationVar(&opt.timeout, "timeout", 10*time.Second, "HTTP timeout (e.g. 5s, 10s)")
+	fs.StringVar(&opt.userAgent, "user-agent", "metar-tool/0.1 (contact: you@example.com)", "User-Agent to send to APIs")
+	fs.StringVar(&opt.output, "output", "", "Write normal output to this file (errors still go to stderr)")
+	fs.BoolVar(&opt.verbose, "verbose", false, "Verbose logging to stderr")
+	fs.BoolVar(&opt.decode, "decode", false, "Decode piped METAR/TAF/JSON from stdin, or the --obs or --taf reports, into human-readable format")
+	fs.StringVar(&opt.format, "format", "text", `For --decode: output format, "text" or "json"`)
+	fs.StringVar(&opt.units, "units", "", `For decoded text: "aviation", "us", "metric" or "si", plus overrides like "temp=F,pressure=hPa,wind=mph" (default: as reported)`)
+	fs.BoolVar(&opt.enrich, "enrich", false, "For --decode of raw METAR text: look up station name, position and time zone (cached)")
+	fs.DurationVar(&opt.staleAfter, "stale-after", defaultStaleAfter, "Warn when a station's latest METAR is older than this (e.g. 75m, 3h; 0 disables)")
+	fs.BoolVar(&opt.failStale, "fail-stale", false, fmt.Sprintf("Exit with status %d when a station's latest METAR is stale", staleExitCode))
+	fs.BoolVar(&opt.derived, "derived", false, "For decoded text: add relative humidity, pressure and density altitude, heat index, wind chill and estimated cloud base")
+	fs.BoolVar(&opt.runways, "runways", false, "For decoded output: headwind and crosswind components for each runway, and the favored runway")
+	fs.Float64Var(&opt.xwindLimit, "crosswind-limit", 0, "For --runways: flag runways whose crosswind, or gust crosswind, exceeds this many knots")
+	fs.BoolVar(&opt.check, "check", false, fmt.Sprintf("For --obs: go/no-go check of the latest METAR and the TAF against the [minimums] in the config file (exit status %d on no-go)", noGoExitCode))
+	fs.IntVar(&opt.checkHours, "check-hours", defaultCheckHours, "For --check: hours of the TAF to check, from now")
+	fs.StringVar(&opt.configFile, "config", "", "Config file (default: $METAR_TOOL_CONFIG, else $XDG_CONFIG_HOME/metar-tool/config)")
+	fs.StringVar(&opt.refDate, "ref-date", "", "For --decode of raw METAR/TAF text: the date the reports are from, YYYY-MM-DD or RFC 3339 (default: now)")
+}
+
+// visitedFlags returns the names of the flags given on the command line.
+func visitedFlags(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// run carries out the mode the options select. args are the positional
+// arguments left after the flags.
+func run(opt options, set map[string]bool, args []string) {
+	// Flags win over the environment, which wins over the config file.
+	cfg, cfgPath, err := loadConfig(opt.configFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	if err := applySettings(&opt, set, cfg, cfgPath); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		os.Exit(1)
+	}
 
 	// Redirect stdout to file if requested
 	if strings.TrimSpace(opt.output) != "" {
@@ -60,7 +144,136 @@ func main() {
 		os.Stdout = f
 	}
 
-	if opt.decode {
+	units, err := parseUnits(opt.units)
+	if err != nil {
+		usageAndExit(fmt.Sprintf("invalid --units: %v", err))
+	}
+
+	stations := []string(opt.obs)
+	if strings.TrimSpace(opt.stations) != "" {
+		ids, err := readStationsFile(opt.stations)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		stations = append(stations, ids...)
+	}
+	stations, err = expandGroups(stations, cfg, cfgPath)
+	if err != nil {
+		usageAndExit(err.Error())
+	}
+	stations, err = normalizeStations(stations)
+	if err != nil {
+		usageAndExit(err.Error())
+	}
+
+	var origin *latLon // --near search point
+	if strings.TrimSpace(opt.near) != "" {
+		p, err := resolveNear(opt.near, opt.home)
+		if err != nil {
+			usageAndExit(fmt.Sprintf("invalid --near: %v", err))
+		}
+		if opt.count < 1 {
+			usageAndExit("--count must be at least 1")
+		}
+		nearby, err := nearestStations(p, opt.count, opt.timeout, opt.userAgent)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		printNearestList(p, nearby)
+		origin = &p
+		for _, s := range nearby {
+			if !slices.Contains(stations, s.ICAOId) {
+				stations = append(stations, s.ICAOId)
+			}
+		}
+	}
+
+	format := strings.ToLower(strings.TrimSpace(opt.format))
+	if opt.decode && format != "text" && format != "json" {
+		usageAndExit(`unsupported --format value (supported: "text", "json")`)
+	}
+	var ref time.Time
+	if strings.TrimSpace(opt.refDate) != "" {
+		ref, err = parseRefDate(strings.TrimSpace(opt.refDate))
+		if err != nil {
+			usageAndExit(fmt.Sprintf("invalid --ref-date: %v", err))
+		}
+	}
+	if opt.xwindLimit < 0 {
+		usageAndExit("--crosswind-limit must be a positive number of knots")
+	}
+	if opt.staleAfter < 0 || (opt.staleAfter > 0 && opt.staleAfter < time.Minute) {
+		usageAndExit("--stale-after must be 0 or at least 1m")
+	}
+	stale := &staleCheck{after: opt.staleAfter}
+	do := decodeOptions{
+		format:    format,
+		pretty:    opt.pretty,
+		timeline:  opt.timeline,
+		units:     units,
+		ref:       ref,
+		stale:     stale,
+		derived:   opt.derived,
+		runways:   opt.runways,
+		xwindKt:   opt.xwindLimit,
+		origin:    origin,
+		enrich:    opt.enrich,
+		timeout:   opt.timeout,
+		userAgent: opt.userAgent,
+	}
+
+	if opt.hours < 0 {
+		usageAndExit("--hours must be a positive number of hours")
+	}
+
+	area, haveArea, err := areaFromOptions(opt)
+	if err != nil {
+		usageAndExit(err.Error())
+	}
+
+	// Without a station or another mode, obs, --check and a bare invocation
+	// use the default station; so do taf and station without one.
+	noMode := !opt.decode && !haveArea && len(opt.info) == 0 && strings.TrimSpace(opt.taf) == "" && strings.TrimSpace(opt.forecast) == ""
+	if len(stations) == 0 && opt.station != "" && (opt.check || noMode || opt.command == "obs") && !haveArea {
+		stations, err = normalizeStations(splitStations(opt.station))
+		if err != nil {
+			usageAndExit(fmt.Sprintf("default station: %v", err))
+		}
+	}
+	switch {
+	case opt.command == "obs" && len(stations) == 0 && !haveArea:
+		usageAndExit("obs needs a station, a @group, --near, --radius or --bbox, or a default station in the config file")
+	case opt.command == "taf" && strings.TrimSpace(opt.taf) == "":
+		if opt.station == "" {
+			usageAndExit("taf needs a station, or a default station in the config file")
+		}
+		opt.taf = opt.station
+	case opt.command == "station" && len(opt.info) == 0:
+		if opt.station == "" {
+			usageAndExit("station needs a station, or a default station in the config file")
+		}
+		opt.info = stationList{opt.station}
+	}
+
+	// --station-info mode
+	if len(opt.info) > 0 {
+		ids, err := expandGroups(opt.info, cfg, cfgPath)
+		if err == nil {
+			ids, err = normalizeStations(ids)
+		}
+		if err != nil {
+			usageAndExit(err.Error())
+		}
+		if err := printStationInfo(ids, opt.timeout, opt.userAgent, opt.obsJSON, opt.pretty); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if opt.decode && len(stations) == 0 && !haveArea && strings.TrimSpace(opt.taf) == "" {
 		in, err := io.ReadAll(os.Stdin)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: read stdin: %v\n", err)
@@ -69,17 +282,75 @@ func main() {
 		if strings.TrimSpace(string(in)) == "" {
 			usageAndExit("--decode expects input on stdin (pipe JSON or raw METAR text)")
 		}
-		if err := decodeFromStdin(in); err != nil {
+		if err := decodeFromStdin(in, do); err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: decode failed: %v\n", err)
 			os.Exit(1)
 		}
+		exitIfStale(stale, opt.failStale)
+		return
+	}
+
+	// --check mode
+	if opt.check {
+		if len(stations) == 0 {
+			usageAndExit("--check needs the stations to check, e.g. --obs KTYS --check, or a default station")
+		}
+		if opt.checkHours < 1 {
+			usageAndExit("--check-hours must be at least 1")
+		}
+		mins, err := minimumsFromConfig(cfg, cfgPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		allGo, err := runCheck(stations, mins, opt.checkHours, do)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		if !allGo {
+			os.Exit(noGoExitCode)
+		}
+		return
+	}
+
+	// --bbox / --radius mode
+	if haveArea {
+		out := obsOutput{asJSON: opt.obsJSON, pretty: opt.pretty, decode: opt.decode, do: do}
+		if err := printAreaObs(area, opt.timeout, opt.userAgent, out); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		exitIfStale(stale, opt.failStale)
 		return
 	}
 
 	// --obs mode
-	if strings.TrimSpace(opt.obs) != "" {
-		station := normalizeStation(opt.obs)
-		if err := printMETARObs(station, opt.timeout, opt.userAgent, opt.obsJSON, opt.pretty); err != nil {
+	if len(stations) > 0 {
+		out := obsOutput{asJSON: opt.obsJSON, pretty: opt.pretty, decode: opt.decode, do: do}
+		if err := printMETARObs(stations, opt.hours, opt.timeout, opt.userAgent, out); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		exitIfStale(stale, opt.failStale)
+		return
+	}
+
+	// --taf mode
+	if strings.TrimSpace(opt.taf) != "" {
+		ids, err := normalizeStations([]string{opt.taf})
+		if err != nil {
+			usageAndExit(err.Error())
+		}
+		station := ids[0]
+		if opt.decode || opt.timeline {
+			if err := decodeTAFFor(station, do); err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := printTAF(station, opt.timeout, opt.userAgent, opt.obsJSON, opt.pretty); err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
 			os.Exit(1)
 		}
@@ -88,16 +359,19 @@ func main() {
 
 	// --forecast mode
 	if strings.TrimSpace(opt.forecast) == "" {
-		usageAndExit(`missing --forecast (e.g. --forecast nws mrx) or use --obs KRDU`)
+		usageAndExit(`missing --forecast (e.g. --forecast nws mrx) or use --obs KRDU, --near lat,lon or --taf KRDU, or set a default station in the config file`)
 	}
-	args := flag.Args()
 
 	switch strings.ToLower(opt.forecast) {
 	case "nws":
-		if len(args) < 1 {
-			usageAndExit(`missing WFO id (e.g. "mrx" or "kmrx")`)
+		id := opt.wfo
+		if len(args) > 0 {
+			id = args[0]
+		}
+		if strings.TrimSpace(id) == "" {
+			usageAndExit(`missing WFO id (e.g. "mrx" or "kmrx"), or set a default wfo in the config file`)
 		}
-		wfo := normalizeWFO(args[0])
+		wfo := normalizeWFO(id)
 		if err := printLatestAFD(wfo, opt.timeout, opt.userAgent); err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
 			os.Exit(1)
@@ -107,19 +381,76 @@ func main() {
 	}
 }
 
+// areaFromOptions builds the --bbox or --radius/--center search, if any.
+func areaFromOptions(opt options) (areaQuery, bool, error) {
+	bboxSet := strings.TrimSpace(opt.bbox) != ""
+	centerSet := strings.TrimSpace(opt.center) != ""
+	switch {
+	case !bboxSet && !centerSet && opt.radius == 0:
+		return areaQuery{}, false, nil
+	case bboxSet && (centerSet || opt.radius != 0):
+		return areaQuery{}, false, fmt.Errorf("use either --bbox or --radius with --center, not both")
+	case bboxSet:
+		b, err := parseBBox(opt.bbox)
+		if err != nil {
+			return areaQuery{}, false, fmt.Errorf("invalid --bbox: %w", err)
+		}
+		return areaQuery{box: b}, true, nil
+	case !centerSet || opt.radius <= 0:
+		return areaQuery{}, false, fmt.Errorf("--radius needs a positive distance in NM and --center lat,lon")
+	}
+	c, err := parseLatLon(opt.center)
+	if err != nil {
+		return areaQuery{}, false, fmt.Errorf("invalid --center: %w", err)
+	}
+	return areaQuery{center: c, radiusNM: opt.radius}, true, nil
+}
+
+// exitIfStale ends the program with staleExitCode when --fail-stale is set
+// and a station's latest report was stale.
+func exitIfStale(c *staleCheck, fail bool) {
+	if fail && len(c.stations) > 0 {
+		os.Exit(staleExitCode)
+	}
+}
+
 func usageAndExit(msg string) {
 	fmt.Fprintln(os.Stderr, "ERROR:", msg)
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintln(os.Stderr, "Usage:")
+	fmt.Fprintf(os.Stderr, " metar-tool COMMAND [flags] [arguments]   # commands: %s; see metar-tool help\n", commandNames())
 	fmt.Fprintln(os.Stderr, " metar-tool --version")
 	fmt.Fprintln(os.Stderr, " metar-tool --obs KRDU")
 	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS --json --pretty")
+	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS,KRDU,KCLT [--decode]")
+	fmt.Fprintln(os.Stderr, " metar-tool --obs tys,anc,yyz   # IATA/FAA codes resolve to ICAO")
+	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS --hours 6 [--json | --decode]")
+	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS,KRDU --stale-after 90m --fail-stale   # exit 3 if a station is stale")
+	fmt.Fprintln(os.Stderr, " metar-tool --near 35.82,-83.99 [--count 5] [--decode]")
+	fmt.Fprintln(os.Stderr, " metar-tool --near home   # uses $METAR_TOOL_HOME=lat,lon or home in the config file")
+	fmt.Fprintln(os.Stderr, " metar-tool --obs @morning   # station group from the config file")
+	fmt.Fprintln(os.Stderr, " metar-tool   # latest METAR for the default station in the config file")
+	fmt.Fprintln(os.Stderr, " metar-tool --radius 30 --center 35.82,-83.99 [--json | --decode]")
+	fmt.Fprintln(os.Stderr, " metar-tool --bbox 35,-85,37,-82 [--json | --decode]")
+	fmt.Fprintln(os.Stderr, " metar-tool --stations-file fields.txt [--json | --decode]")
+	fmt.Fprintln(os.Stderr, " metar-tool --taf KTYS [--json [--pretty]]")
+	fmt.Fprintln(os.Stderr, " metar-tool --taf KTYS --timeline")
+	fmt.Fprintln(os.Stderr, " metar-tool --station-info KTYS[,KRDU] [--json [--pretty]]")
 	fmt.Fprintln(os.Stderr, " metar-tool --forecast nws mrx")
-	fmt.Fprintln(os.Stderr, " metar-tool --decode   # reads stdin (pipe JSON or raw METAR)")
+	fmt.Fprintln(os.Stderr, " metar-tool --decode   # reads stdin (pipe JSON, raw METAR or TAF)")
+	fmt.Fprintln(os.Stderr, " metar-tool --decode --format json [--pretty]")
+	fmt.Fprintln(os.Stderr, " metar-tool --decode --units metric,temp=F")
+	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS --decode --derived   # humidity, density altitude, ...")
+	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS --decode --runways [--crosswind-limit 12]")
+	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS --check [--check-hours 3]   # go/no-go against [minimums]; exit 4 on no-go")
+	fmt.Fprintln(os.Stderr, " metar-tool --decode --enrich   # adds station name, position and time zone")
+	fmt.Fprintln(os.Stderr, " metar-tool --decode --ref-date 2026-01-15 < archived-metars.txt")
 	fmt.Fprintln(os.Stderr)
 	fmt.Fprintln(os.Stderr, "Examples:")
 	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS")
 	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS --json | metar-tool --decode")
 	fmt.Fprintln(os.Stderr, " metar-tool --obs KTYS | metar-tool --decode")
+	fmt.Fprintln(os.Stderr, " metar-tool --taf KTYS | metar-tool --decode")
+	fmt.Fprintln(os.Stderr, " metar-tool --decode --format json < ktys-20260115-1337Z.txt")
 	os.Exit(2)
 }
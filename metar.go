@@ -0,0 +1,584 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Observation is a METAR or SPECI report parsed into typed fields. Optional
+// groups that were not present in the report are left nil/empty.
+type Observation struct {
+	Raw        string // the report line as given
+	ReportType string // METAR or SPECI; empty when the report omits it
+	Station    string
+	Time       string // DDHHMMZ group as reported
+	Day        int
+	Hour       int
+	Minute     int
+	ObservedAt time.Time    // Day/Hour/Minute as a full UTC time; see resolveTime
+	Stale      bool         // the station's latest report, older than --stale-after
+	Runways    []runwayWind // with --runways, for the JSON output
+	Origin     *latLon      // area or --near search point, for the JSON distance
+	Modifier   string       // AUTO or COR
+	Missing    bool         // NIL: the station filed no report
+	Conditions
+	RVR         []RunwayVisualRange
+	TempC       *int
+	DewpointC   *int
+	Altimeter   *Altimeter
+	NoSigChange bool            // NOSIG trend
+	Trends      []ForecastGroup // BECMG/TEMPO trend forecasts
+	Other       []string        // unrecognised groups between the altimeter and RMK
+	Remarks     string          // everything after RMK, verbatim
+	RMK         *Remarks
+
+	// ReportedCategory is the flight category supplied alongside the report
+	// by the data source (aviationweather.gov fltCat), if any.
+	ReportedCategory string
+
+	// Info is the station's metadata, when it has been looked up.
+	Info *stationInfo
+}
+
+// Conditions are the wind, visibility, weather and sky groups shared by
+// observations and forecast periods.
+type Conditions struct {
+	Wind       *Wind
+	Visibility *Visibility
+	Weather    []WeatherGroup
+	Sky        []SkyLayer
+	CAVOK      bool // ceiling and visibility OK; replaces visibility, weather and sky
+}
+
+// Wind is a surface wind group such as 19012G18KT, optionally followed by a
+// variable direction group such as 180V240.
+type Wind struct {
+	Direction int  // degrees true; not meaningful when Variable is set
+	Variable  bool // VRB
+	Speed     int
+	Gust      *int
+	Unit      string // KT, MPS or KMH
+	Variation *WindVariation
+}
+
+// WindVariation is the 180V240 group reported when the direction varies by
+// 60° or more.
+type WindVariation struct {
+	From int
+	To   int
+}
+
+// Calm reports whether the wind group was 00000KT.
+func (w *Wind) Calm() bool {
+	return !w.Variable && w.Direction == 0 && w.Speed == 0 && w.Gust == nil
+}
+
+// Ceiling returns the height in feet AGL of the lowest broken or overcast
+// layer or vertical visibility, or nil when there is no ceiling.
+func (c *Conditions) Ceiling() *int {
+	var ceiling *int
+	for _, l := range c.Sky {
+		if l.Base == nil {
+			continue
+		}
+		switch l.Cover {
+		case "BKN", "OVC", "VV":
+			if ceiling == nil || *l.Base < *ceiling {
+				ceiling = l.Base
+			}
+		}
+	}
+	return ceiling
+}
+
+// CeilingNotReported reports whether there is no measured ceiling but a
+// broken or overcast layer or vertical visibility is given without a height
+// (BKN///, VV///): the sky is obscured or covered at an unknown height.
+func (c *Conditions) CeilingNotReported() bool {
+	if c.Ceiling() != nil {
+		return false
+	}
+	for _, l := range c.Sky {
+		if l.Base == nil && (l.Cover == "BKN" || l.Cover == "OVC" || l.Cover == "VV") {
+			return true
+		}
+	}
+	return false
+}
+
+// Visibility is the prevailing visibility group.
+type Visibility struct {
+	Value    float64 // in Unit
+	Unit     string  // SM or m
+	Modifier string  // "P" (more than), "M" (less than) or ""
+	Minimum  *MinimumVisibility
+}
+
+// MinimumVisibility is the ICAO directional minimum visibility, e.g. 4000NE.
+type MinimumVisibility struct {
+	Meters    int
+	Direction string
+}
+
+// metersPerStatuteMile converts between statute miles and meters.
+const metersPerStatuteMile = 1609.344
+
+// StatuteMiles returns the visibility in statute miles.
+func (v *Visibility) StatuteMiles() float64 {
+	if v.Unit == "m" {
+		return v.Value / metersPerStatuteMile
+	}
+	return v.Value
+}
+
+// RunwayVisualRange is an RVR group such as R28L/2400FT, R06/0600V1200FT/U or
+// R10/M0600N. Values are in Unit; Max is set only for variable RVR.
+type RunwayVisualRange struct {
+	Runway      string
+	Min         int
+	MinModifier string // "P" (more than) or "M" (less than)
+	Max         *int
+	MaxModifier string
+	Unit        string // FT or m
+	Tendency    string // U (up), D (down), N (no change) or ""
+}
+
+var reRVR = regexp.MustCompile(`^R(\d{2}[LCR]?)/([PM])?(\d{4})(?:V([PM])?(\d{4}))?(FT)?/?([UDN])?$`)
+
+func parseRVR(t string) (RunwayVisualRange, bool) {
+	m := reRVR.FindStringSubmatch(t)
+	if m == nil {
+		return RunwayVisualRange{}, false
+	}
+	r := RunwayVisualRange{Runway: m[1], MinModifier: m[2], MaxModifier: m[4], Unit: "m", Tendency: m[7]}
+	r.Min, _ = strconv.Atoi(m[3])
+	r.Max = optionalInt(m[5])
+	if m[6] == "FT" {
+		r.Unit = "FT"
+	}
+	return r, true
+}
+
+// WeatherGroup is a single present-weather group such as -RA, +TSRA or VCSH.
+type WeatherGroup struct {
+	Raw        string
+	Intensity  string // "-", "+" or ""
+	Vicinity   bool
+	Descriptor string   // MI, PR, BC, DR, BL, SH, TS or FZ
+	Phenomena  []string // two-letter codes, e.g. RA, BR; nil if unrecognised
+}
+
+// SkyLayer is a single sky-condition group.
+type SkyLayer struct {
+	Cover string // SKC, CLR, NSC, NCD, FEW, SCT, BKN, OVC, VV; "///" when not reported
+	Base  *int   // feet AGL; nil when the base is "///"
+	Type  string // CB or TCU for convective cloud, "///" when not reported
+}
+
+// Altimeter is the altimeter setting group.
+type Altimeter struct {
+	Value float64 // in Unit
+	Unit  string  // inHg or hPa (QNH)
+}
+
+// resolveTime sets ObservedAt from the DDHHMMZ group, taking the month and
+// year from ref. A zero ref means a current report, which cannot be from the
+// future: it resolves to the latest matching time up to now. An ObservedAt
+// that is already set is left alone.
+func (o *Observation) resolveTime(ref time.Time) {
+	if o.Time == "" || !o.ObservedAt.IsZero() {
+		return
+	}
+	if ref.IsZero() {
+		o.ObservedAt = latestDayTime(time.Now(), o.Day, o.Hour, o.Minute)
+		return
+	}
+	o.ObservedAt = resolveDayTime(ref, o.Day, o.Hour, o.Minute)
+}
+
+// ParseMETAR parses the first non-empty line of raw as a METAR or SPECI
+// report. Groups the parser does not recognise before the sky condition are
+// kept as weather groups so nothing in the report is silently dropped.
+func ParseMETAR(raw string) (*Observation, error) {
+	var line string
+	for _, ln := range strings.Split(raw, "\n") {
+		ln = strings.TrimSpace(ln)
+		if ln != "" {
+			line = ln
+			break
+		}
+	}
+	if line == "" {
+		return nil, fmt.Errorf("no METAR content found")
+	}
+
+	obs := &Observation{Raw: line}
+	tokens := strings.Fields(line)
+	if len(tokens) < 3 {
+		return obs, nil
+	}
+
+	i := 0
+	if tokens[i] == "METAR" || tokens[i] == "SPECI" {
+		obs.ReportType = tokens[i]
+		i++
+	}
+
+	obs.Station = tokens[i]
+	i++
+	obs.Time = tokens[i]
+	obs.Day, obs.Hour, obs.Minute, _ = parseDDHHMMZ(tokens[i])
+	i++
+
+	// Optional AUTO/COR
+	if i < len(tokens) && (tokens[i] == "AUTO" || tokens[i] == "COR") {
+		obs.Modifier = tokens[i]
+		i++
+	}
+
+	// A NIL report has nothing after it.
+	if i < len(tokens) && strings.TrimSuffix(tokens[i], "=") == "NIL" {
+		obs.Missing = true
+		return obs, nil
+	}
+
+	// Wind
+	if i < len(tokens) {
+		if w, ok := parseWind(tokens[i]); ok {
+			obs.Wind = w
+			i++
+			// optional variable dir 180V240
+			if i < len(tokens) {
+				if v, ok := parseWindVariation(tokens[i]); ok {
+					w.Variation = v
+					i++
+				}
+			}
+		}
+	}
+
+	// Visibility, or CAVOK in place of visibility, weather and sky
+	if i < len(tokens) {
+		if tokens[i] == "CAVOK" {
+			obs.CAVOK = true
+			i++
+		} else if vis, used := parseVisibility(tokens[i:]); used > 0 {
+			obs.Visibility = vis
+			i += used
+		}
+	}
+
+	// RVR and weather tokens until sky/temps/alt/trend/RMK
+	for i < len(tokens) {
+		t := tokens[i]
+		if isSkyToken(t) || isTempDewToken(t) || isAltimeterToken(t) || isTrendToken(t) || t == "RMK" {
+			break
+		}
+		if rvr, ok := parseRVR(t); ok {
+			obs.RVR = append(obs.RVR, rvr)
+			i++
+			continue
+		}
+		obs.Weather = append(obs.Weather, parseWeatherGroup(t))
+		i++
+	}
+
+	// Sky
+	for i < len(tokens) && isSkyToken(tokens[i]) {
+		obs.Sky = append(obs.Sky, parseSkyLayer(tokens[i]))
+		i++
+	}
+
+	// Temp/Dew
+	if i < len(tokens) && isTempDewToken(tokens[i]) {
+		obs.TempC, obs.DewpointC = parseTempDew(tokens[i])
+		i++
+	}
+
+	// Altimeter
+	if i < len(tokens) {
+		if a, ok := parseAltimeter(tokens[i]); ok {
+			obs.Altimeter = a
+			i++
+		}
+	}
+
+	// Supplementary groups and ICAO trend forecast
+	for i < len(tokens) && tokens[i] != "RMK" {
+		t := tokens[i]
+		switch {
+		case t == "NOSIG":
+			obs.NoSigChange = true
+			i++
+		case t == "BECMG" || t == "TEMPO":
+			j := i + 1
+			for j < len(tokens) && tokens[j] != "RMK" && !isTrendToken(tokens[j]) {
+				j++
+			}
+			obs.Trends = append(obs.Trends, parseTrendGroup(tokens[i:j]))
+			i = j
+		default:
+			obs.Other = append(obs.Other, t)
+			i++
+		}
+	}
+
+	// RMK
+	if i < len(tokens) {
+		obs.Remarks = strings.Join(tokens[i+1:], " ")
+		obs.RMK = parseRemarks(obs.Remarks)
+	}
+
+	return obs, nil
+}
+
+// parseDDHHMMZ splits a 200053Z style group into day, hour and minute.
+func parseDDHHMMZ(t string) (day, hour, minute int, ok bool) {
+	if len(t) != 7 || t[6] != 'Z' || !looksNumeric(t[:6]) {
+		return 0, 0, 0, false
+	}
+	day, _ = strconv.Atoi(t[0:2])
+	hour, _ = strconv.Atoi(t[2:4])
+	minute, _ = strconv.Atoi(t[4:6])
+	return day, hour, minute, true
+}
+
+// isTrendToken reports whether t starts or replaces an ICAO trend forecast.
+func isTrendToken(t string) bool {
+	return t == "NOSIG" || t == "BECMG" || t == "TEMPO"
+}
+
+func parseWind(tok string) (*Wind, bool) {
+	// 19004KT, VRB03KT, 19012G18KT, 00000KT, 24008MPS, 27015KMH
+	var unit string
+	for _, u := range []string{"KT", "MPS", "KMH"} {
+		if strings.HasSuffix(tok, u) {
+			unit = u
+			break
+		}
+	}
+	if unit == "" {
+		return nil, false
+	}
+	core := strings.TrimSuffix(tok, unit)
+	w := &Wind{Unit: unit}
+
+	if g := strings.Index(core, "G"); g >= 0 {
+		gust, err := strconv.Atoi(core[g+1:])
+		if err != nil {
+			return nil, false
+		}
+		w.Gust = &gust
+		core = core[:g]
+	}
+	if len(core) < 5 {
+		return nil, false
+	}
+
+	if strings.HasPrefix(core, "VRB") {
+		w.Variable = true
+	} else {
+		dir, err := strconv.Atoi(core[:3])
+		if err != nil {
+			return nil, false
+		}
+		w.Direction = dir
+	}
+	spd, err := strconv.Atoi(core[3:])
+	if err != nil {
+		return nil, false
+	}
+	w.Speed = spd
+	return w, true
+}
+
+func parseWindVariation(tok string) (*WindVariation, bool) {
+	// 180V240
+	if len(tok) != 7 || tok[3] != 'V' || !looksNumeric(tok[:3]) || !looksNumeric(tok[4:]) {
+		return nil, false
+	}
+	from, _ := strconv.Atoi(tok[:3])
+	to, _ := strconv.Atoi(tok[4:])
+	return &WindVariation{From: from, To: to}, true
+}
+
+var (
+	reMetricVis    = regexp.MustCompile(`^(\d{4})(?:NDV)?$`)
+	reDirectionVis = regexp.MustCompile(`^(\d{4})(N|NE|E|SE|S|SW|W|NW)$`)
+)
+
+// parseVisibility recognises "10SM", "P6SM", "M1/4SM" and the two-token
+// "1 1/2SM" form, as well as ICAO meters ("9999", "0800") optionally followed
+// by a directional minimum ("4000NE"). It returns the number of tokens
+// consumed.
+func parseVisibility(tokens []string) (*Visibility, int) {
+	if len(tokens) == 0 {
+		return nil, 0
+	}
+	t0 := tokens[0]
+	if m := reMetricVis.FindStringSubmatch(t0); m != nil {
+		meters, _ := strconv.Atoi(m[1])
+		v := &Visibility{Value: float64(meters), Unit: "m"}
+		if meters == 9999 {
+			// 9999 means 10 km or more.
+			v.Value = 10000
+			v.Modifier = "P"
+		}
+		if len(tokens) > 1 {
+			if d := reDirectionVis.FindStringSubmatch(tokens[1]); d != nil {
+				min, _ := strconv.Atoi(d[1])
+				v.Minimum = &MinimumVisibility{Meters: min, Direction: d[2]}
+				return v, 2
+			}
+		}
+		return v, 1
+	}
+	if strings.HasSuffix(t0, "SM") {
+		v, ok := parseStatuteMiles(strings.TrimSuffix(t0, "SM"))
+		if !ok {
+			return nil, 0
+		}
+		return v, 1
+	}
+	// "1 1/2SM"
+	if len(tokens) > 1 && strings.HasSuffix(tokens[1], "SM") && looksNumeric(t0) {
+		whole, _ := strconv.Atoi(t0)
+		v, ok := parseStatuteMiles(strings.TrimSuffix(tokens[1], "SM"))
+		if !ok || v.Modifier != "" {
+			return nil, 0
+		}
+		v.Value += float64(whole)
+		return v, 2
+	}
+	return nil, 0
+}
+
+func parseStatuteMiles(core string) (*Visibility, bool) {
+	v := &Visibility{Unit: "SM"}
+	if strings.HasPrefix(core, "P") || strings.HasPrefix(core, "M") {
+		v.Modifier = core[:1]
+		core = core[1:]
+	}
+	if num, den, ok := strings.Cut(core, "/"); ok {
+		n, err1 := strconv.Atoi(num)
+		d, err2 := strconv.Atoi(den)
+		if err1 != nil || err2 != nil || d == 0 {
+			return nil, false
+		}
+		v.Value = float64(n) / float64(d)
+		return v, true
+	}
+	n, err := strconv.Atoi(core)
+	if err != nil {
+		return nil, false
+	}
+	v.Value = float64(n)
+	return v, true
+}
+
+func parseWeatherGroup(tok string) WeatherGroup {
+	g := WeatherGroup{Raw: tok}
+	t := strings.ToUpper(tok)
+
+	if strings.HasPrefix(t, "+") || strings.HasPrefix(t, "-") {
+		g.Intensity = t[:1]
+		t = t[1:]
+	}
+	if strings.HasPrefix(t, "VC") {
+		g.Vicinity = true
+		t = t[2:]
+	}
+	for _, d := range []string{"MI", "PR", "BC", "DR", "BL", "SH", "TS", "FZ"} {
+		if strings.HasPrefix(t, d) {
+			g.Descriptor = d
+			t = t[2:]
+			break
+		}
+	}
+
+	var phen []string
+	for len(t) >= 2 {
+		if decodeWxPhenomena(t[:2]) == "" {
+			return g
+		}
+		phen = append(phen, t[:2])
+		t = t[2:]
+	}
+	if t == "" {
+		g.Phenomena = phen
+	}
+	return g
+}
+
+// isKnownWeather reports whether g was recognised as a present-weather group
+// rather than an unknown token.
+func isKnownWeather(g WeatherGroup) bool {
+	if len(g.Phenomena) > 0 {
+		return true
+	}
+	if g.Descriptor != "TS" && g.Descriptor != "SH" {
+		return false
+	}
+	core := g.Intensity + g.Descriptor
+	if g.Vicinity {
+		core = g.Intensity + "VC" + g.Descriptor
+	}
+	return strings.ToUpper(g.Raw) == core
+}
+
+// reSkyLayer matches a cloud layer or vertical visibility group: FEW050,
+// BKN030CB, SCT025TCU, VV002, and the automated-station forms with "///" for
+// an unreported cover, base or type (BKN///, ///015, //////TCU).
+var reSkyLayer = regexp.MustCompile(`^(FEW|SCT|BKN|OVC|VV|///)(\d{3}|///)(CB|TCU|///)?$`)
+
+func parseSkyLayer(t string) SkyLayer {
+	t = strings.ToUpper(t)
+	switch t {
+	case "SKC", "CLR", "NSC", "NCD":
+		return SkyLayer{Cover: t}
+	}
+	m := reSkyLayer.FindStringSubmatch(t)
+	if m == nil {
+		return SkyLayer{Cover: t}
+	}
+	l := SkyLayer{Cover: m[1], Type: m[3]}
+	if m[2] != "///" {
+		hundreds, _ := strconv.Atoi(m[2])
+		ft := hundreds * 100
+		l.Base = &ft
+	}
+	return l
+}
+
+func parseTempDew(t string) (tempC, dewC *int) {
+	parts := strings.SplitN(t, "/", 2)
+	return parseMInt(parts[0]), parseMInt(parts[1])
+}
+
+// parseMInt parses METAR signed integers where a leading M means minus.
+func parseMInt(s string) *int {
+	s = strings.TrimSpace(s)
+	neg := strings.HasPrefix(s, "M")
+	n, err := strconv.Atoi(strings.TrimPrefix(s, "M"))
+	if err != nil {
+		return nil
+	}
+	if neg {
+		n = -n
+	}
+	return &n
+}
+
+func parseAltimeter(t string) (*Altimeter, bool) {
+	if !isAltimeterToken(t) {
+		return nil, false
+	}
+	n, _ := strconv.Atoi(t[1:])
+	if strings.HasPrefix(strings.ToUpper(t), "Q") {
+		return &Altimeter{Value: float64(n), Unit: "hPa"}, true
+	}
+	return &Altimeter{Value: float64(n) / 100, Unit: "inHg"}, true
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestParseMETAR(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		modifier   string
+		missing    bool
+		visibility *Visibility
+		rvr        []RunwayVisualRange
+		sky        []SkyLayer
+		weather    []string
+	}{
+		{
+			name:    "NIL",
+			raw:     "METAR KXYZ 151753Z NIL=",
+			missing: true,
+		},
+		{
+			name:       "AUTO",
+			raw:        "METAR KTYS 151753Z AUTO 21010KT 10SM CLR 20/10 A3000 RMK AO2",
+			modifier:   "AUTO",
+			visibility: &Visibility{Value: 10, Unit: "SM"},
+			sky:        []SkyLayer{{Cover: "CLR"}},
+		},
+		{
+			name:       "COR",
+			raw:        "METAR KTYS 151753Z COR 21010KT 10SM FEW050 20/10 A3000",
+			modifier:   "COR",
+			visibility: &Visibility{Value: 10, Unit: "SM"},
+			sky:        []SkyLayer{{Cover: "FEW", Base: intPtr(5000)}},
+		},
+		{
+			name:       "mixed fraction visibility",
+			raw:        "KTYS 151753Z 21010KT 1 1/2SM BR OVC005 20/19 A3000",
+			visibility: &Visibility{Value: 1.5, Unit: "SM"},
+			sky:        []SkyLayer{{Cover: "OVC", Base: intPtr(500)}},
+			weather:    []string{"BR"},
+		},
+		{
+			name:       "fraction visibility",
+			raw:        "KTYS 151753Z 00000KT 3/4SM -SN BKN008 M02/M03 A2992",
+			visibility: &Visibility{Value: 0.75, Unit: "SM"},
+			sky:        []SkyLayer{{Cover: "BKN", Base: intPtr(800)}},
+			weather:    []string{"-SN"},
+		},
+		{
+			name:       "less than a quarter mile",
+			raw:        "KTYS 151753Z 00000KT M1/4SM FG VV001 10/10 A2992",
+			visibility: &Visibility{Value: 0.25, Unit: "SM", Modifier: "M"},
+			sky:        []SkyLayer{{Cover: "VV", Base: intPtr(100)}},
+			weather:    []string{"FG"},
+		},
+		{
+			name:       "variable RVR with tendency",
+			raw:        "KTYS 151753Z 00000KT 1/4SM R05/1200V2000FT/U FG VV002 10/10 A2992",
+			visibility: &Visibility{Value: 0.25, Unit: "SM"},
+			rvr:        []RunwayVisualRange{{Runway: "05", Min: 1200, Max: intPtr(2000), Unit: "FT", Tendency: "U"}},
+			sky:        []SkyLayer{{Cover: "VV", Base: intPtr(200)}},
+			weather:    []string{"FG"},
+		},
+		{
+			name:       "RVR above the reportable range",
+			raw:        "KTYS 151753Z 00000KT 1/2SM R23L/P6000FT BR OVC002 10/10 A2992",
+			visibility: &Visibility{Value: 0.5, Unit: "SM"},
+			rvr:        []RunwayVisualRange{{Runway: "23L", Min: 6000, MinModifier: "P", Unit: "FT"}},
+			sky:        []SkyLayer{{Cover: "OVC", Base: intPtr(200)}},
+			weather:    []string{"BR"},
+		},
+		{
+			name:       "metric RVR",
+			raw:        "EGLL 151750Z 24005KT 0400 R27L/M0050N FG VV/// 08/08 Q1015",
+			visibility: &Visibility{Value: 400, Unit: "m"},
+			rvr:        []RunwayVisualRange{{Runway: "27L", Min: 50, MinModifier: "M", Unit: "m", Tendency: "N"}},
+			sky:        []SkyLayer{{Cover: "VV"}},
+			weather:    []string{"FG"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := ParseMETAR(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseMETAR(%q): %v", tt.raw, err)
+			}
+			if o.Modifier != tt.modifier {
+				t.Errorf("Modifier = %q, want %q", o.Modifier, tt.modifier)
+			}
+			if o.Missing != tt.missing {
+				t.Errorf("Missing = %v, want %v", o.Missing, tt.missing)
+			}
+			if !reflect.DeepEqual(o.Visibility, tt.visibility) {
+				t.Errorf("Visibility = %+v, want %+v", o.Visibility, tt.visibility)
+			}
+			if !reflect.DeepEqual(o.RVR, tt.rvr) {
+				t.Errorf("RVR = %+v, want %+v", o.RVR, tt.rvr)
+			}
+			if !reflect.DeepEqual(o.Sky, tt.sky) {
+				t.Errorf("Sky = %+v, want %+v", o.Sky, tt.sky)
+			}
+			var weather []string
+			for _, w := range o.Weather {
+				weather = append(weather, w.Raw)
+			}
+			if !reflect.DeepEqual(weather, tt.weather) {
+				t.Errorf("Weather = %q, want %q", weather, tt.weather)
+			}
+		})
+	}
+}
+
+func TestParseMETAREmpty(t *testing.T) {
+	if _, err := ParseMETAR(" \n "); err == nil {
+		t.Error("ParseMETAR of blank input: no error")
+	}
+}
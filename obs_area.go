@@ -0,0 +1,167 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// areaQuery selects reporting stations by position: every station inside
+// box, or, when radiusNM is set, within radiusNM of center.
+type areaQuery struct {
+	box      bbox
+	center   latLon
+	radiusNM float64
+}
+
+func (a areaQuery) String() string {
+	if a.radiusNM > 0 {
+		return fmt.Sprintf("within %g NM of %s", a.radiusNM, a.center)
+	}
+	return "in bbox " + a.box.query()
+}
+
+// searchCenter is the point distances are measured from: the center of a
+// radius search, or the middle of the box.
+func (a areaQuery) searchCenter() latLon {
+	if a.radiusNM > 0 {
+		return a.center
+	}
+	return a.box.center()
+}
+
+// areaObs is one observation found by an area search.
+type areaObs struct {
+	raw        json.RawMessage
+	m          awMetar
+	distanceNM float64 // NaN when the record has no position
+	bearing    float64
+}
+
+// printAreaObs fetches the current METAR for every station in the area and
+// prints them nearest first, measured from the search center (the middle of
+// the box for a bbox search).
+func printAreaObs(a areaQuery, timeout time.Duration, userAgent string, out obsOutput) error {
+	found, err := fetchAreaObs(a, timeout, userAgent)
+	if err != nil {
+		return err
+	}
+	if len(found) == 0 {
+		return fmt.Errorf("no METAR returned %s", a)
+	}
+
+	switch {
+	case out.decode && out.do.format == "json":
+		// decodeAWMetars flags stale stations itself.
+		arr := make([]awMetar, len(found))
+		for i, f := range found {
+			arr[i] = f.m
+		}
+		do, center := out.do, a.searchCenter()
+		do.origin = &center
+		return decodeAWMetars(arr, do)
+	case out.decode:
+		stale := flagStale(out.do.stale, found, areaStation, areaObsTime)
+		for i, f := range found {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("Distance: %s\n", describeDistance(f))
+			printHumanFromAWJSON(f.m, stale[i], out.do)
+		}
+		return nil
+	case out.asJSON:
+		flagStale(out.do.stale, found, areaStation, areaObsTime)
+		raws := make([]json.RawMessage, len(found))
+		for i, f := range found {
+			raws[i] = f.raw
+		}
+		var b []byte
+		if out.pretty {
+			b, err = json.MarshalIndent(raws, "", "  ")
+		} else {
+			b, err = json.Marshal(raws)
+		}
+		if err != nil {
+			return fmt.Errorf("encode JSON: %w", err)
+		}
+		fmt.Println(string(b))
+		return nil
+	default:
+		flagStale(out.do.stale, found, areaStation, areaObsTime)
+		for _, f := range found {
+			if raw := strings.TrimSpace(f.m.RawOb); raw != "" {
+				fmt.Println(raw)
+			}
+		}
+		return nil
+	}
+}
+
+// fetchAreaObs queries the aviationweather.gov bbox for the area, drops
+// stations outside the radius, and sorts the rest by distance.
+func fetchAreaObs(a areaQuery, timeout time.Duration, userAgent string) ([]areaObs, error) {
+	box, center := a.box, a.searchCenter()
+	if a.radiusNM > 0 {
+		box = bboxAround(a.center, a.radiusNM)
+	}
+
+	q := url.Values{}
+	q.Set("bbox", box.query())
+	q.Set("taf", "false")
+	body, err := fetchAWProduct("metar", q, timeout, userAgent, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []json.RawMessage
+	if strings.TrimSpace(string(body)) != "" {
+		if err := json.Unmarshal(body, &records); err != nil {
+			return nil, fmt.Errorf("decode JSON: %w (first 200 bytes: %q)", err, preview(body, 200))
+		}
+	}
+
+	var found []areaObs
+	for _, r := range records {
+		f := areaObs{raw: r, distanceNM: math.NaN()}
+		if err := json.Unmarshal(r, &f.m); err != nil {
+			return nil, fmt.Errorf("decode aviationweather.gov METAR JSON: %w", err)
+		}
+		if f.m.Lat.Valid() && f.m.Lon.Valid() {
+			p := latLon{Lat: *f.m.Lat.Value, Lon: *f.m.Lon.Value}
+			f.distanceNM = distanceNM(center, p)
+			f.bearing = bearingDeg(center, p)
+		}
+		if a.radiusNM > 0 && !(f.distanceNM <= a.radiusNM) {
+			continue
+		}
+		found = append(found, f)
+	}
+
+	// Stations without a position sort last.
+	sort.SliceStable(found, func(i, j int) bool {
+		di, dj := found[i].distanceNM, found[j].distanceNM
+		if math.IsNaN(dj) {
+			return !math.IsNaN(di)
+		}
+		return di < dj
+	})
+	return found, nil
+}
+
+func areaStation(f areaObs) string    { return f.m.ICAOId }
+func areaObsTime(f areaObs) time.Time { return f.m.ObsTime.Time }
+
+func describeDistance(f areaObs) string {
+	if math.IsNaN(f.distanceNM) {
+		return "unknown (no station position)"
+	}
+	if f.distanceNM < 0.5 {
+		return "at the search center"
+	}
+	return fmt.Sprintf("%.0f NM, bearing %s true", f.distanceNM, formatBearing(f.bearing))
+}
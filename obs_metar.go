@@ -5,37 +5,192 @@ import (
 	"fmt"
 	"net/http"
 	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
-func printMETARObs(station string, timeout time.Duration, userAgent string, asJSON bool, pretty bool) error {
-	u, _ := url.Parse("https://aviationweather.gov/api/data/metar")
-	q := u.Query()
-	q.Set("ids", station)
+// obsOutput selects how fetched observations are printed: raw text, the API
+// JSON, or decoded using the --decode options.
+type obsOutput struct {
+	asJSON bool
+	pretty bool
+	decode bool
+	do     decodeOptions
+}
+
+// printMETARObs fetches METARs for every station in one request and prints
+// the reports grouped per station, in the order given. With hours > 0 it
+// returns every METAR and SPECI from the last hours, oldest first; otherwise
+// only the latest report.
+func printMETARObs(stations []string, hours int, timeout time.Duration, userAgent string, out obsOutput) error {
+	q := url.Values{}
+	q.Set("ids", strings.Join(stations, ","))
 	q.Set("taf", "false")
-	if asJSON {
-		q.Set("format", "json")
-	} else {
-		q.Set("format", "raw")
+	if hours > 0 {
+		q.Set("hours", strconv.Itoa(hours))
 	}
-	u.RawQuery = q.Encode()
 
-	client := &http.Client{Timeout: timeout}
-	accept := "text/plain"
-	if asJSON {
-		accept = "application/json"
+	body, err := fetchAWProduct("metar", q, timeout, userAgent, out.asJSON || out.decode)
+	if err != nil {
+		return err
 	}
+	label := strings.Join(stations, ", ")
 
-	body, err := httpGET(client, u.String(), userAgent, accept)
+	if !out.asJSON && !out.decode {
+		var lines []string
+		for _, ln := range strings.Split(string(body), "\n") {
+			if ln = strings.TrimSpace(ln); ln != "" {
+				lines = append(lines, ln)
+			}
+		}
+		groups, missing := orderByStation(lines, reportStation, stations)
+		if len(groups) == 0 {
+			return fmt.Errorf("no METAR returned for %s", label)
+		}
+		warnMissing(out.do.stale, missing)
+		var all []string
+		for _, g := range groups {
+			sortChronological(g, rawObsTime)
+			all = append(all, g...)
+		}
+		flagStale(out.do.stale, all, reportStation, rawObsTime)
+		for i, g := range groups {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Println(strings.Join(g, "\n"))
+		}
+		return nil
+	}
+
+	var records []json.RawMessage
+	if err := json.Unmarshal(body, &records); err != nil {
+		return fmt.Errorf("decode JSON: %w (first 200 bytes: %q)", err, preview(body, 200))
+	}
+	groups, missing := orderByStation(records, jsonStation, stations)
+	if len(groups) == 0 {
+		return fmt.Errorf("no METAR returned for %s", label)
+	}
+	warnMissing(out.do.stale, missing)
+
+	var ordered []json.RawMessage
+	for _, g := range groups {
+		sortChronological(g, jsonObsTime)
+		ordered = append(ordered, g...)
+	}
+
+	if out.decode {
+		arr := make([]awMetar, len(ordered))
+		for i, r := range ordered {
+			if err := json.Unmarshal(r, &arr[i]); err != nil {
+				return fmt.Errorf("decode aviationweather.gov METAR JSON: %w", err)
+			}
+		}
+		return decodeAWMetars(arr, out.do)
+	}
+
+	flagStale(out.do.stale, ordered, jsonStation, jsonObsTime)
+	if out.pretty {
+		b, err := json.MarshalIndent(ordered, "", "  ")
+		if err != nil {
+			return fmt.Errorf("encode JSON: %w", err)
+		}
+		fmt.Println(string(b))
+		return nil
+	}
+	b, err := json.Marshal(ordered)
 	if err != nil {
-		return fmt.Errorf("fetch metar: %w", err)
+		return fmt.Errorf("encode JSON: %w", err)
+	}
+	fmt.Println(string(b))
+	return nil
+}
+
+// orderByStation groups items by station in the order stations lists them;
+// items for stations that were not asked for come last. It also returns the
+// stations that had no items.
+func orderByStation[T any](items []T, station func(T) string, stations []string) (groups [][]T, missing []string) {
+	byStation := map[string][]T{}
+	var extra []string
+	for _, it := range items {
+		id := strings.ToUpper(station(it))
+		if _, ok := byStation[id]; !ok && !slices.Contains(stations, id) {
+			extra = append(extra, id)
+		}
+		byStation[id] = append(byStation[id], it)
+	}
+	for _, id := range append(append([]string{}, stations...), extra...) {
+		if g := byStation[id]; len(g) > 0 {
+			groups = append(groups, g)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	return groups, missing
+}
+
+// sortChronological orders reports oldest first. Reports whose time cannot
+// be determined keep their place relative to each other.
+func sortChronological[T any](reports []T, at func(T) time.Time) {
+	sort.SliceStable(reports, func(i, j int) bool {
+		return at(reports[i]).Before(at(reports[j]))
+	})
+}
+
+// rawObsTime is the time of a raw METAR line, taken as a current report.
+func rawObsTime(line string) time.Time {
+	o, err := ParseMETAR(line)
+	if err != nil {
+		return time.Time{}
+	}
+	o.resolveTime(time.Time{})
+	return o.ObservedAt
+}
+
+func jsonStation(r json.RawMessage) string {
+	var id struct {
+		ICAOId string `json:"icaoId"`
+	}
+	_ = json.Unmarshal(r, &id)
+	return id.ICAOId
+}
+
+func jsonObsTime(r json.RawMessage) time.Time {
+	var t struct {
+		ObsTime awTime `json:"obsTime"`
+	}
+	_ = json.Unmarshal(r, &t)
+	return t.ObsTime.Time
+}
+
+// warnMissing reports the stations that returned no METAR. A station that
+// returns nothing has stopped reporting for longer than any stale report,
+// so with the stale check on it counts as stale.
+func warnMissing(c *staleCheck, stations []string) {
+	for _, s := range stations {
+		fmt.Fprintf(os.Stderr, "WARNING: no METAR returned for %s\n", s)
+		if c != nil && c.after > 0 {
+			c.stations = append(c.stations, s)
+		}
+	}
+}
+
+// printAWProduct fetches one aviationweather.gov data API product (metar,
+// taf, ...) and prints it as raw text or JSON.
+func printAWProduct(product, label, station string, q url.Values, timeout time.Duration, userAgent string, asJSON bool, pretty bool) error {
+	body, err := fetchAWProduct(product, q, timeout, userAgent, asJSON)
+	if err != nil {
+		return err
 	}
 
 	if asJSON {
 		trim := strings.TrimSpace(string(body))
 		if trim == "" || trim == "[]" {
-			return fmt.Errorf("no METAR returned for %s", station)
+			return fmt.Errorf("no %s returned for %s", label, station)
 		}
 		if pretty {
 			var v any
@@ -55,8 +210,30 @@ func printMETARObs(station string, timeout time.Duration, userAgent string, asJS
 
 	out := strings.TrimSpace(string(body))
 	if out == "" {
-		return fmt.Errorf("no METAR returned for %s", station)
+		return fmt.Errorf("no %s returned for %s", label, station)
 	}
 	fmt.Println(out)
 	return nil
 }
+
+func fetchAWProduct(product string, q url.Values, timeout time.Duration, userAgent string, asJSON bool) ([]byte, error) {
+	u, _ := url.Parse("https://aviationweather.gov/api/data/" + product)
+	if asJSON {
+		q.Set("format", "json")
+	} else {
+		q.Set("format", "raw")
+	}
+	u.RawQuery = q.Encode()
+
+	client := &http.Client{Timeout: timeout}
+	accept := "text/plain"
+	if asJSON {
+		accept = "application/json"
+	}
+
+	body, err := httpGET(client, u.String(), userAgent, accept)
+	if err != nil {
+		return nil, fmt.Errorf("fetch %s: %w", product, err)
+	}
+	return body, nil
+}
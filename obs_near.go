@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// homeEnv holds the default location used by --near home, as lat,lon.
+const homeEnv = "METAR_TOOL_HOME"
+
+// nearSearchRadiiNM are the search radii tried in turn until enough
+// METAR-reporting stations are found.
+var nearSearchRadiiNM = []float64{25, 50, 100, 200}
+
+// nearbyStation is a METAR-reporting station with its distance and bearing
+// from the search point.
+type nearbyStation struct {
+	stationInfo
+	distanceNM float64
+	bearing    float64
+}
+
+// resolveNear parses a --near value: "lat,lon", or "home" for the home
+// location from METAR_TOOL_HOME or the config file (nil when neither sets
+// it).
+func resolveNear(s string, home *latLon) (latLon, error) {
+	s = strings.TrimSpace(s)
+	if !strings.EqualFold(s, "home") {
+		return parseLatLon(s)
+	}
+	if home == nil {
+		return latLon{}, fmt.Errorf("no home location set (export %s=lat,lon or set home in the config file)", homeEnv)
+	}
+	return *home, nil
+}
+
+// nearestStations returns up to count METAR-reporting stations closest to
+// p, nearest first, widening the search until count are found or the
+// largest radius has been tried.
+func nearestStations(p latLon, count int, timeout time.Duration, userAgent string) ([]nearbyStation, error) {
+	var found []nearbyStation
+	for _, r := range nearSearchRadiiNM {
+		q := url.Values{}
+		q.Set("bbox", bboxAround(p, r).query())
+		infos, err := fetchStationInfo(q, timeout, userAgent)
+		if err != nil {
+			return nil, err
+		}
+
+		found = found[:0]
+		for _, s := range infos {
+			if !s.reportsMETAR() || s.ICAOId == "" {
+				continue
+			}
+			d := distanceNM(p, s.position())
+			if d > r {
+				continue
+			}
+			found = append(found, nearbyStation{stationInfo: s, distanceNM: d, bearing: bearingDeg(p, s.position())})
+		}
+		if len(found) >= count {
+			break
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool { return found[i].distanceNM < found[j].distanceNM })
+	if len(found) > count {
+		found = found[:count]
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("no METAR-reporting station within %.0f NM of %s", nearSearchRadiiNM[len(nearSearchRadiiNM)-1], p)
+	}
+	return found, nil
+}
+
+// printNearestList writes the stations found by --near to stderr, so stdout
+// keeps the normal --obs output.
+func printNearestList(p latLon, stations []nearbyStation) {
+	fmt.Fprintf(os.Stderr, "Nearest METAR stations to %s:\n", p)
+	for _, s := range stations {
+		fmt.Fprintf(os.Stderr, "  %-4s  %5.1f NM  %s  %s\n", s.ICAOId, s.distanceNM, formatBearing(s.bearing), stationPlace(s.stationInfo))
+	}
+}
+
+// stationPlace renders a station's name and location, e.g.
+// "Knoxville/McGhee Tyson Arpt, TN, US".
+func stationPlace(s stationInfo) string {
+	var parts []string
+	for _, v := range []string{s.Site, s.State, s.Country} {
+		if v = strings.TrimSpace(v); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+func printTAF(station string, timeout time.Duration, userAgent string, asJSON bool, pretty bool) error {
+	q := url.Values{}
+	q.Set("ids", station)
+	return printAWProduct("taf", "TAF", station, q, timeout, userAgent, asJSON, pretty)
+}
+
+// decodeTAFFor fetches the current TAF for station and decodes it as
+// --decode does piped TAF text: as text, a timeline or decoded JSON.
+func decodeTAFFor(station string, do decodeOptions) error {
+	q := url.Values{}
+	q.Set("ids", station)
+	body, err := fetchAWProduct("taf", q, do.timeout, do.userAgent, false)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(string(body)) == "" {
+		return fmt.Errorf("no TAF returned for %s", station)
+	}
+	if err := decodeTAFText(string(body), do); err != nil {
+		return fmt.Errorf("decode TAF: %w", err)
+	}
+	return nil
+}
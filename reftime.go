@@ -0,0 +1,126 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// resolveDayTime turns a day-of-month and time, as carried by METAR and TAF
+// groups, into a full UTC timestamp: the candidate in the month before, the
+// month of, or the month after ref that lies closest to ref. Hour 24 is
+// accepted and means midnight at the end of the day.
+func resolveDayTime(ref time.Time, day, hour, minute int) time.Time {
+	ref = ref.UTC()
+	var best time.Time
+	for _, offset := range []int{-1, 0, 1} {
+		first := time.Date(ref.Year(), ref.Month()+time.Month(offset), 1, 0, 0, 0, 0, time.UTC)
+		d := first.AddDate(0, 0, day-1)
+		if d.Month() != first.Month() {
+			continue // day does not exist in this month
+		}
+		t := d.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		if best.IsZero() || absDuration(t.Sub(ref)) < absDuration(best.Sub(ref)) {
+			best = t
+		}
+	}
+	return best
+}
+
+// clockSkew is how far in the future latestDayTime still accepts a time, to
+// allow for a slow local clock.
+const clockSkew = 15 * time.Minute
+
+// latestDayTime is like resolveDayTime but returns the latest candidate that
+// is not after now (give or take clockSkew), for reports that were just
+// issued.
+func latestDayTime(now time.Time, day, hour, minute int) time.Time {
+	now = now.UTC()
+	for offset := 0; offset >= -2; offset-- {
+		first := time.Date(now.Year(), now.Month()+time.Month(offset), 1, 0, 0, 0, 0, time.UTC)
+		d := first.AddDate(0, 0, day-1)
+		if d.Month() != first.Month() {
+			continue
+		}
+		if t := d.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute); !t.After(now.Add(clockSkew)) {
+			return t
+		}
+	}
+	return resolveDayTime(now, day, hour, minute)
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// parseRefDate parses a --ref-date value: a date (2026-01-15), taken as noon
+// UTC, or an RFC 3339 timestamp.
+func parseRefDate(s string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", s); err == nil {
+		return t.Add(12 * time.Hour), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected YYYY-MM-DD or an RFC 3339 time, got %q", s)
+	}
+	return t.UTC(), nil
+}
+
+// describeObservedTime renders an observation time as an "Observed:" line in
+// UTC with its age and, when zone names a known IANA zone, a "Local time:"
+// line.
+func describeObservedTime(t time.Time, zone string) []string {
+	out := []string{fmt.Sprintf("Observed: %s UTC (%s)", t.UTC().Format("2006-01-02 15:04"), describeAge(time.Since(t)))}
+	if local, ok := localTime(t, zone); ok {
+		out = append(out, "Local time: "+local.Format("2006-01-02 15:04 MST"))
+	}
+	return out
+}
+
+// localTime converts t to the IANA zone, reporting false when zone is empty
+// or unknown.
+func localTime(t time.Time, zone string) (time.Time, bool) {
+	if zone == "" {
+		return time.Time{}, false
+	}
+	loc, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.In(loc), true
+}
+
+// describeAge renders how long ago something happened: "just now",
+// "47 minutes ago", "2 hours 5 minutes ago", "3 days ago". A negative age
+// (a time in the future) reads "in ...".
+func describeAge(d time.Duration) string {
+	future := d < 0
+	d = absDuration(d).Round(time.Minute)
+	var s string
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		s = plural(int(d.Minutes()), "minute")
+	case d < 48*time.Hour:
+		s = plural(int(d.Hours()), "hour")
+		if m := int(d.Minutes()) % 60; m > 0 {
+			s += " " + plural(m, "minute")
+		}
+	default:
+		s = plural(int(d.Hours()/24), "day")
+	}
+	if future {
+		return "in " + s
+	}
+	return s + " ago"
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", n, unit)
+}
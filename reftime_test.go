@@ -0,0 +1,55 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func utc(year int, month time.Month, day, hour, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, time.UTC)
+}
+
+func TestResolveDayTime(t *testing.T) {
+	tests := []struct {
+		name              string
+		ref               time.Time
+		day, hour, minute int
+		want              time.Time
+	}{
+		{"same day", utc(2026, 10, 15, 18, 0), 15, 17, 53, utc(2026, 10, 15, 17, 53)},
+		{"last month, over the year end", utc(2026, 1, 1, 0, 10), 31, 23, 53, utc(2025, 12, 31, 23, 53)},
+		{"next month, over the year end", utc(2025, 12, 31, 23, 50), 1, 0, 5, utc(2026, 1, 1, 0, 5)},
+		{"next month after a short month", utc(2026, 2, 28, 23, 0), 1, 6, 0, utc(2026, 3, 1, 6, 0)},
+		{"leap day", utc(2024, 3, 1, 1, 0), 29, 23, 0, utc(2024, 2, 29, 23, 0)},
+		{"end of a 30-day month", utc(2026, 5, 1, 2, 0), 30, 22, 0, utc(2026, 4, 30, 22, 0)},
+		{"hour 24", utc(2026, 10, 15, 18, 0), 15, 24, 0, utc(2026, 10, 16, 0, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveDayTime(tt.ref, tt.day, tt.hour, tt.minute); !got.Equal(tt.want) {
+				t.Errorf("resolveDayTime(%v, %02d%02d%02dZ) = %v, want %v", tt.ref, tt.day, tt.hour, tt.minute, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLatestDayTime(t *testing.T) {
+	tests := []struct {
+		name              string
+		now               time.Time
+		day, hour, minute int
+		want              time.Time
+	}{
+		{"earlier today", utc(2026, 10, 15, 18, 0), 15, 17, 53, utc(2026, 10, 15, 17, 53)},
+		{"within the clock skew", utc(2026, 10, 15, 17, 50), 15, 17, 53, utc(2026, 10, 15, 17, 53)},
+		{"later today is last month", utc(2026, 10, 15, 12, 0), 15, 17, 53, utc(2026, 9, 15, 17, 53)},
+		{"last month, over the year end", utc(2026, 1, 1, 0, 10), 31, 23, 53, utc(2025, 12, 31, 23, 53)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := latestDayTime(tt.now, tt.day, tt.hour, tt.minute); !got.Equal(tt.want) {
+				t.Errorf("latestDayTime(%v, %02d%02d%02dZ) = %v, want %v", tt.now, tt.day, tt.hour, tt.minute, got, tt.want)
+			}
+		})
+	}
+}
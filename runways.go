@@ -0,0 +1,186 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// runwaysCSV lists the runways of the bundled stations, one pair per row.
+//
+//go:embed runways.csv
+var runwaysCSV string
+
+// runwayEnd is one direction of a runway.
+type runwayEnd struct {
+	Name    string  // designator, e.g. 05L
+	Heading float64 // degrees magnetic, from the designator
+}
+
+// runwayTable maps ICAO identifiers to their runway ends.
+var runwayTable = sync.OnceValue(func() map[string][]runwayEnd {
+	r := csv.NewReader(strings.NewReader(runwaysCSV))
+	r.Comment = '#'
+	rows, err := r.ReadAll()
+	if err != nil {
+		panic(fmt.Sprintf("runways.csv: %v", err))
+	}
+	out := map[string][]runwayEnd{}
+	for _, row := range rows[1:] { // header
+		for _, name := range strings.Split(row[1], "/") {
+			n, err := strconv.Atoi(strings.TrimRight(name, "LCR"))
+			if err != nil || n < 1 || n > 36 {
+				panic(fmt.Sprintf("runways.csv: bad runway %q for %s", name, row[0]))
+			}
+			out[row[0]] = append(out[row[0]], runwayEnd{Name: name, Heading: float64(n * 10)})
+		}
+	}
+	return out
+})
+
+// runwayWind is the wind resolved along and across one runway end, in knots.
+type runwayWind struct {
+	Runway        string
+	Heading       float64
+	Headwind      float64  // negative for a tailwind
+	Crosswind     float64  // positive from the right
+	GustHeadwind  *float64 // components of the gust, when one is reported
+	GustCrosswind *float64
+	ExceedsLimit  bool // the crosswind, or the gust crosswind, is over the limit
+	Favored       bool // the most headwind of the runways within the limit
+}
+
+// runwayWinds resolves the reported wind onto every runway of the station.
+// The METAR direction is true and runway headings are magnetic, so the wind
+// is turned by the magnetic variation first. limitKt is the crosswind limit
+// in knots, 0 for none. It returns nil when the station has no runway data,
+// the wind has no direction (calm, variable or not reported) or the
+// variation is unknown: components from the true wind would be off by the
+// variation, which exceeds 15° in places.
+func runwayWinds(o *Observation, limitKt float64) []runwayWind {
+	ends := runwayTable()[o.Station]
+	w := o.Wind
+	if len(ends) == 0 || w == nil || w.Variable || w.Calm() {
+		return nil
+	}
+	v, ok := magneticVariation(o)
+	if !ok {
+		return nil
+	}
+	dir := float64(w.Direction) - v
+	toKt := metersPerSecond[w.Unit] / metersPerSecond["KT"]
+	components := func(speed int, heading float64) (head, cross float64) {
+		angle := radians(dir - heading)
+		v := float64(speed) * toKt
+		return v * math.Cos(angle), v * math.Sin(angle)
+	}
+
+	out := make([]runwayWind, len(ends))
+	for i, e := range ends {
+		rw := runwayWind{Runway: e.Name, Heading: e.Heading}
+		rw.Headwind, rw.Crosswind = components(w.Speed, e.Heading)
+		maxCross := math.Abs(rw.Crosswind)
+		if w.Gust != nil {
+			h, c := components(*w.Gust, e.Heading)
+			rw.GustHeadwind, rw.GustCrosswind = &h, &c
+			maxCross = math.Max(maxCross, math.Abs(c))
+		}
+		rw.ExceedsLimit = limitKt > 0 && math.Round(maxCross) > limitKt
+		out[i] = rw
+	}
+
+	// Favor the most headwind, among the runways within the limit if any are.
+	withinLimit := slices.ContainsFunc(out, func(rw runwayWind) bool { return !rw.ExceedsLimit })
+	best := math.Inf(-1)
+	for _, rw := range out {
+		if !withinLimit || !rw.ExceedsLimit {
+			best = math.Max(best, rw.Headwind)
+		}
+	}
+	for i, rw := range out {
+		out[i].Favored = (!withinLimit || !rw.ExceedsLimit) && rw.Headwind > best-0.5 // parallel runways tie
+	}
+	return out
+}
+
+// printRunwayWinds prints the runway wind section of decoded text.
+func printRunwayWinds(o *Observation, limitKt float64, u displayUnits) {
+	d, magnetic := magneticWindDirection(o)
+	switch {
+	case o.Wind == nil:
+		return
+	case o.Wind.Calm():
+		fmt.Println("Runway winds: calm, no components")
+		return
+	case o.Wind.Variable:
+		fmt.Println("Runway winds: variable direction, any runway may have the full wind across it")
+		return
+	case len(runwayTable()[o.Station]) == 0:
+		fmt.Printf("Runway winds: no runway data for %s\n", o.Station)
+		return
+	case !magnetic:
+		fmt.Printf("Runway winds: no components, the magnetic variation at %s is unknown (wind %03d° true, runway headings magnetic)\n", o.Station, o.Wind.Direction)
+		return
+	}
+
+	rws := runwayWinds(o, limitKt)
+	basis := fmt.Sprintf("wind %03d° magnetic", d)
+	if limitKt > 0 {
+		basis += "; crosswind limit " + knots(limitKt, u)
+	}
+	fmt.Printf("Runway winds (%s):\n", basis)
+	var favored []string
+	for _, rw := range rws {
+		fmt.Printf("  %s\n", describeRunwayWind(rw, limitKt, u))
+		if rw.Favored {
+			favored = append(favored, rw.Runway)
+		}
+	}
+	fmt.Printf("  Favored: %s\n", strings.Join(favored, ", "))
+}
+
+// describeRunwayWind renders one runway end, e.g. "23R (230°): headwind
+// 9 kt, crosswind 5 kt from the right; in gusts headwind 14 kt, crosswind
+// 8 kt from the right".
+func describeRunwayWind(rw runwayWind, limitKt float64, u displayUnits) string {
+	s := fmt.Sprintf("%s (%03.0f°): %s, %s", rw.Runway, rw.Heading, describeHeadwind(rw.Headwind, u), describeCrosswind(rw.Crosswind, u))
+	if rw.GustHeadwind != nil {
+		s += fmt.Sprintf("; in gusts %s, %s", describeHeadwind(*rw.GustHeadwind, u), describeCrosswind(*rw.GustCrosswind, u))
+	}
+	if rw.ExceedsLimit {
+		s += fmt.Sprintf("; EXCEEDS %s CROSSWIND LIMIT", knots(limitKt, u))
+	}
+	return s
+}
+
+func describeHeadwind(kt float64, u displayUnits) string {
+	if math.Round(kt) < 0 {
+		return "tailwind " + knots(-kt, u)
+	}
+	return "headwind " + knots(kt, u)
+}
+
+func describeCrosswind(kt float64, u displayUnits) string {
+	switch {
+	case math.Round(kt) > 0:
+		return "crosswind " + knots(kt, u) + " from the right"
+	case math.Round(kt) < 0:
+		return "crosswind " + knots(-kt, u) + " from the left"
+	}
+	return "no crosswind"
+}
+
+// knots formats a speed in knots in the --units wind unit, kt by default.
+func knots(kt float64, u displayUnits) string {
+	to := u.Wind
+	if to == "" {
+		to = "kt"
+	}
+	v := math.Abs(kt) * metersPerSecond["kt"] / metersPerSecond[to]
+	return fmt.Sprintf("%.0f %s", math.Round(v), speedLabels[to])
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultStaleAfter is the age past which a station's latest report is
+// stale: routine METARs are hourly, plus a quarter hour for late filing.
+const defaultStaleAfter = 75 * time.Minute
+
+// staleExitCode is the exit status with --fail-stale when a station's latest
+// report is stale.
+const staleExitCode = 3
+
+// staleCheck flags stations whose latest report is older than after, or
+// that returned none, and remembers them for the exit status.
+type staleCheck struct {
+	after    time.Duration // 0 disables the check
+	stations []string
+}
+
+// flagStale checks the latest item of each station against c and returns
+// the indexes of the stale ones. Older items in a history are not stale in
+// themselves, and items without a time are never stale. Each stale station
+// is reported on stderr.
+func flagStale[T any](c *staleCheck, items []T, station func(T) string, at func(T) time.Time) map[int]bool {
+	if c == nil || c.after <= 0 {
+		return nil
+	}
+	latest := map[string]int{}
+	var order []string
+	for i, it := range items {
+		id := strings.ToUpper(station(it))
+		j, ok := latest[id]
+		if !ok {
+			order = append(order, id)
+		}
+		if !ok || !at(it).Before(at(items[j])) {
+			latest[id] = i
+		}
+	}
+
+	stale := map[int]bool{}
+	now := time.Now()
+	for _, id := range order {
+		i := latest[id]
+		t := at(items[i])
+		if t.IsZero() || now.Sub(t) <= c.after {
+			continue
+		}
+		stale[i] = true
+		c.stations = append(c.stations, id)
+		fmt.Fprintf(os.Stderr, "WARNING: latest report for %s is %s old (%s UTC); the station may have stopped reporting\n",
+			id, strings.TrimSuffix(describeAge(now.Sub(t)), " ago"), t.UTC().Format("2006-01-02 15:04"))
+	}
+	return stale
+}
+
+// staleLine follows the observation time in decoded text for a stale report.
+const staleLine = "STALE: no newer report; the station may have stopped reporting"
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// stationInfo is one record of the aviationweather.gov stationinfo JSON.
+type stationInfo struct {
+	ICAOId   string   `json:"icaoId"`
+	IATAId   string   `json:"iataId"`
+	FAAId    string   `json:"faaId"`
+	WMOId    string   `json:"wmoId"`
+	Lat      float64  `json:"lat"`
+	Lon      float64  `json:"lon"`
+	Elev     float64  `json:"elev"` // meters
+	Site     string   `json:"site"`
+	State    string   `json:"state"`
+	Country  string   `json:"country"`
+	Priority int      `json:"priority"`
+	SiteType []string `json:"siteType"` // METAR, TAF, ...
+
+	TimeZone string `json:"timeZone,omitempty"` // IANA zone from stationTimeZone, not the API
+}
+
+func (s stationInfo) position() latLon {
+	return latLon{Lat: s.Lat, Lon: s.Lon}
+}
+
+// reportsMETAR reports whether the station issues METARs.
+func (s stationInfo) reportsMETAR() bool {
+	return slices.Contains(s.SiteType, "METAR")
+}
+
+// lookupStations returns metadata for the given ICAO identifiers, keyed by
+// identifier. Stations the catalog does not know are missing from the map.
+// Stations found in the cache are not asked for again, and the ones asked
+// for are cached.
+func lookupStations(ids []string, timeout time.Duration, userAgent string) (map[string]stationInfo, error) {
+	out := map[string]stationInfo{}
+	var query []string
+	for _, id := range ids {
+		if s, ok := cachedStationInfo(id); ok {
+			out[id] = s
+		} else {
+			query = append(query, id)
+		}
+	}
+	if len(query) > 0 {
+		q := url.Values{}
+		q.Set("ids", strings.Join(query, ","))
+		infos, err := fetchStationInfo(q, timeout, userAgent)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range infos {
+			id := strings.ToUpper(s.ICAOId)
+			if slices.Contains(query, id) {
+				cacheStationInfo(s)
+			}
+			out[id] = s
+		}
+	}
+	for id, s := range out {
+		s.TimeZone = stationTimeZone(id, s.Country, s.State, s.Lon)
+		out[id] = s
+	}
+	return out, nil
+}
+
+// stationInfoFromAW builds station metadata from the fields an
+// aviationweather.gov METAR record carries: the name ("Knoxville/Tyson
+// Arpt, TN, US"), position and elevation.
+func stationInfoFromAW(m awMetar) *stationInfo {
+	if !m.Lat.Valid() || !m.Lon.Valid() {
+		return nil
+	}
+	s := &stationInfo{ICAOId: strings.TrimSpace(m.ICAOId), Lat: *m.Lat.Value, Lon: *m.Lon.Value, SiteType: []string{"METAR"}}
+	if m.Elev.Valid() {
+		s.Elev = *m.Elev.Value
+	}
+	parts := strings.Split(m.Name, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	switch {
+	case len(parts) >= 3:
+		s.Site = strings.Join(parts[:len(parts)-2], ", ")
+		s.State, s.Country = parts[len(parts)-2], parts[len(parts)-1]
+	case len(parts) == 2:
+		s.Site, s.Country = parts[0], parts[1]
+	default:
+		s.Site = parts[0]
+	}
+	s.TimeZone = stationTimeZone(strings.ToUpper(s.ICAOId), s.Country, s.State, s.Lon)
+	return s
+}
+
+// describeStationInfo renders station metadata as "Label: value" lines for
+// the decoded output.
+func describeStationInfo(s *stationInfo) []string {
+	var out []string
+	if s.Site != "" {
+		out = append(out, "Name: "+s.Site)
+	}
+	var loc []string
+	for _, v := range []string{s.State, s.Country} {
+		if v != "" {
+			loc = append(loc, v)
+		}
+	}
+	if len(loc) > 0 {
+		out = append(out, "Location: "+strings.Join(loc, ", "))
+	}
+	out = append(out, fmt.Sprintf("Position: %.4f, %.4f", s.Lat, s.Lon))
+	out = append(out, fmt.Sprintf("Elevation: %.0f m (%.0f ft)", s.Elev, s.Elev/metersPerFoot))
+	if s.TimeZone != "" {
+		out = append(out, "Time zone: "+s.TimeZone)
+	}
+	return out
+}
+
+// printStationInfo prints metadata for each station, in the order given, as
+// text or as a JSON array of stationinfo records.
+func printStationInfo(ids []string, timeout time.Duration, userAgent string, asJSON, pretty bool) error {
+	found, err := lookupStations(ids, timeout, userAgent)
+	if err != nil {
+		return err
+	}
+	var ordered []stationInfo
+	var missing []string
+	for _, id := range ids {
+		if s, ok := found[id]; ok {
+			ordered = append(ordered, s)
+		} else {
+			missing = append(missing, id)
+		}
+	}
+	if len(ordered) == 0 {
+		return fmt.Errorf("no station information returned for %s", strings.Join(ids, ", "))
+	}
+	for _, id := range missing {
+		fmt.Fprintf(os.Stderr, "WARNING: no station information returned for %s\n", id)
+	}
+
+	if asJSON {
+		return printJSON(ordered, pretty)
+	}
+	for i, s := range ordered {
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("Station: %s\n", s.ICAOId)
+		for _, ln := range describeStationInfo(&s) {
+			fmt.Println(ln)
+		}
+		var ids []string
+		for _, id := range []struct{ label, v string }{{"IATA", s.IATAId}, {"FAA", s.FAAId}, {"WMO", s.WMOId}} {
+			if id.v != "" {
+				ids = append(ids, id.label+" "+id.v)
+			}
+		}
+		if len(ids) > 0 {
+			fmt.Printf("Other identifiers: %s\n", strings.Join(ids, ", "))
+		}
+		if len(s.SiteType) > 0 {
+			fmt.Printf("Reports: %s\n", strings.Join(s.SiteType, ", "))
+		}
+	}
+	return nil
+}
+
+// stationCacheTTL is how long a cached station record is reused. Station
+// metadata changes rarely.
+const stationCacheTTL = 30 * 24 * time.Hour
+
+// fetchStationInfo queries the stationinfo endpoint. It does not cache: a
+// bbox query returns hundreds of stations nobody asked for.
+func fetchStationInfo(q url.Values, timeout time.Duration, userAgent string) ([]stationInfo, error) {
+	q.Set("format", "json")
+	body, err := fetchAWProduct("stationinfo", q, timeout, userAgent, true)
+	if err != nil {
+		return nil, err
+	}
+	var stations []stationInfo
+	if strings.TrimSpace(string(body)) != "" {
+		if err := json.Unmarshal(body, &stations); err != nil {
+			return nil, fmt.Errorf("decode stationinfo JSON: %w (first 200 bytes: %q)", err, preview(body, 200))
+		}
+	}
+	for _, s := range stations {
+		cacheStationInfo(s)
+	}
+	return stations, nil
+}
+
+// cachedStationInfo returns the cached record of station id when it is
+// younger than stationCacheTTL.
+func cachedStationInfo(id string) (stationInfo, bool) {
+	var s stationInfo
+	path := stationCachePath(id)
+	if path == "" {
+		return s, false
+	}
+	fi, err := os.Stat(path)
+	if err != nil || time.Since(fi.ModTime()) >= stationCacheTTL {
+		return s, false
+	}
+	b, err := os.ReadFile(path)
+	if err != nil || json.Unmarshal(b, &s) != nil || !strings.EqualFold(s.ICAOId, id) {
+		return stationInfo{}, false
+	}
+	return s, true
+}
+
+// cacheStationInfo writes the record of one station to the cache. Only
+// stations the catalog returned are cached, so an empty answer is asked
+// again next time. Cache problems are not fatal.
+func cacheStationInfo(s stationInfo) {
+	path := stationCachePath(strings.ToUpper(s.ICAOId))
+	if path == "" {
+		return
+	}
+	b, err := json.Marshal(s)
+	if err != nil {
+		return
+	}
+	if os.MkdirAll(filepath.Dir(path), 0o755) == nil {
+		_ = os.WriteFile(path, b, 0o644)
+	}
+}
+
+// stationCachePath returns the cache file for station id, or "" when the id
+// is not a station identifier or there is no user cache directory.
+func stationCachePath(id string) string {
+	if !validStation(id) {
+		return ""
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "metar-tool", "stationinfo", id+".json")
+}
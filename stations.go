@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// stationList is a flag.Value collecting station identifiers from repeated
+// flags and comma- or space-separated lists: --obs KTYS,KRDU --obs KCLT.
+type stationList []string
+
+func (l *stationList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stationList) Set(v string) error {
+	*l = append(*l, splitStations(v)...)
+	return nil
+}
+
+// splitStations splits a list of station identifiers on commas and white
+// space.
+func splitStations(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	})
+}
+
+// readStationsFile reads station identifiers from a file, one or more per
+// line. Text after a "#" is a comment.
+func readStationsFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open stations file: %w", err)
+	}
+	defer f.Close()
+
+	var ids []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		ids = append(ids, splitStations(line)...)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read stations file: %w", err)
+	}
+	return ids, nil
+}
+
+// normalizeStations upper-cases and validates station identifiers, resolves
+// IATA and FAA codes to ICAO (see resolveStation), and drops duplicates,
+// keeping the order they were given in.
+func normalizeStations(ids []string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, id := range ids {
+		id, err := resolveStation(normalizeStation(id))
+		if err != nil {
+			return nil, err
+		}
+		if !validStation(id) {
+			return nil, fmt.Errorf("invalid station identifier %q (expected an ICAO id such as KTYS)", id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			out = append(out, id)
+		}
+	}
+	return out, nil
+}
+
+// validStation reports whether id looks like a station identifier: three or
+// four letters and digits, starting with a letter.
+func validStation(id string) bool {
+	if len(id) < 3 || len(id) > 4 || id[0] < 'A' || id[0] > 'Z' {
+		return false
+	}
+	for _, r := range id {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// reportStation returns the station identifier of a raw METAR/SPECI line.
+func reportStation(line string) string {
+	f := strings.Fields(line)
+	if len(f) > 1 && (f[0] == "METAR" || f[0] == "SPECI") {
+		return f[1]
+	}
+	if len(f) > 0 {
+		return f[0]
+	}
+	return ""
+}
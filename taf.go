@@ -0,0 +1,295 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TAF is a terminal aerodrome forecast. Groups[0] is the base forecast that
+// applies from the start of the validity period; the remaining groups are the
+// FM/BECMG/TEMPO/PROB change groups in the order they were issued.
+type TAF struct {
+	Raw        string
+	Station    string
+	Amended    bool // AMD
+	Corrected  bool // COR
+	IssueTime  string
+	Issued     *TAFTime
+	ValidFrom  *TAFTime
+	ValidTo    *TAFTime
+	Cancelled  bool // CNL
+	NoForecast bool // NIL
+	Groups     []ForecastGroup
+	Remarks    string
+}
+
+// TAFTime is a day-of-month and time, always UTC. Hour may be 24 at the end
+// of a period.
+type TAFTime struct {
+	Day    int
+	Hour   int
+	Minute int
+}
+
+func (t TAFTime) String() string {
+	return fmt.Sprintf("%02d/%02d%02dZ", t.Day, t.Hour, t.Minute)
+}
+
+// ForecastGroup is the base forecast or one change group of a TAF.
+type ForecastGroup struct {
+	Raw         string
+	Change      string // "" for the base forecast, FM, BECMG, TEMPO or PROB
+	Probability int    // 30 or 40 for PROB groups
+	From        *TAFTime
+	To          *TAFTime // for FM groups, the next FM group or the end of the TAF
+	Conditions
+	NoSigWeather bool     // NSW
+	TrendTimes   []string // METAR trends only: FMhhmm, TLhhmm, AThhmm
+	WindShear    *WindShear
+	Other        []string
+}
+
+// WindShear is the non-convective low-level wind shear group WShhh/dddffKT.
+type WindShear struct {
+	Height int // feet AGL
+	Wind   Wind
+}
+
+var (
+	reTAFPeriod = regexp.MustCompile(`^(\d{2})(\d{2})/(\d{2})(\d{2})$`)
+	reTAFFrom   = regexp.MustCompile(`^FM(\d{2})(\d{2})(\d{2})$`)
+	reProb      = regexp.MustCompile(`^PROB(\d{2})$`)
+	reWindShear = regexp.MustCompile(`^WS(\d{3})/(\w+KT)$`)
+	reTrendTime = regexp.MustCompile(`^(FM|TL|AT)\d{4}$`)
+)
+
+// looksLikeTAF reports whether s is TAF text rather than a METAR: it either
+// starts with TAF or carries a DDHH/DDHH validity period in the header.
+func looksLikeTAF(s string) bool {
+	tokens := strings.Fields(s)
+	if len(tokens) == 0 {
+		return false
+	}
+	if tokens[0] == "TAF" {
+		return true
+	}
+	for _, t := range tokens[:min(len(tokens), 4)] {
+		if reTAFPeriod.MatchString(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseTAF parses TAF text. The report may span several lines, as it does in
+// the aviationweather.gov raw output.
+func ParseTAF(raw string) (*TAF, error) {
+	tokens := strings.Fields(raw)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("no TAF content found")
+	}
+	taf := &TAF{Raw: strings.Join(tokens, " ")}
+
+	i := 0
+	if tokens[i] == "TAF" {
+		i++
+	}
+	for i < len(tokens) && (tokens[i] == "AMD" || tokens[i] == "COR") {
+		if tokens[i] == "AMD" {
+			taf.Amended = true
+		} else {
+			taf.Corrected = true
+		}
+		i++
+	}
+	if i >= len(tokens) {
+		return nil, fmt.Errorf("TAF is missing the station identifier")
+	}
+	taf.Station = tokens[i]
+	i++
+
+	if i < len(tokens) {
+		if d, h, m, ok := parseDDHHMMZ(tokens[i]); ok {
+			taf.IssueTime = tokens[i]
+			taf.Issued = &TAFTime{Day: d, Hour: h, Minute: m}
+			i++
+		}
+	}
+	if i < len(tokens) {
+		if from, to, ok := parseTAFPeriod(tokens[i]); ok {
+			taf.ValidFrom, taf.ValidTo = from, to
+			i++
+		}
+	}
+	if taf.ValidFrom == nil {
+		// A NIL or cancelled TAF may carry no validity period at all:
+		// "TAF KXYZ 151720Z NIL=".
+		if i < len(tokens) {
+			switch strings.TrimSuffix(tokens[i], "=") {
+			case "NIL":
+				taf.NoForecast = true
+				return taf, nil
+			case "CNL":
+				taf.Cancelled = true
+				return taf, nil
+			}
+		}
+		return nil, fmt.Errorf("TAF for %s has no DDHH/DDHH validity period", taf.Station)
+	}
+
+	// Split the remaining tokens into the base forecast and change groups.
+	base := ForecastGroup{From: taf.ValidFrom, To: taf.ValidTo}
+	cur := &base
+	var groups []ForecastGroup
+	var body []string
+	flush := func() {
+		cur.Raw = strings.Join(body, " ")
+		cur.parseBody(body)
+		groups = append(groups, *cur)
+		body = nil
+	}
+
+	for ; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t == "NIL":
+			taf.NoForecast = true
+			continue
+		case t == "CNL":
+			taf.Cancelled = true
+			continue
+		case t == "RMK":
+			taf.Remarks = strings.Join(tokens[i+1:], " ")
+			i = len(tokens)
+			continue
+		}
+
+		if m := reTAFFrom.FindStringSubmatch(t); m != nil {
+			flush()
+			d, _ := strconv.Atoi(m[1])
+			h, _ := strconv.Atoi(m[2])
+			mi, _ := strconv.Atoi(m[3])
+			cur = &ForecastGroup{Change: "FM", From: &TAFTime{Day: d, Hour: h, Minute: mi}}
+			body = []string{t}
+			continue
+		}
+		if t == "BECMG" || t == "TEMPO" || reProb.MatchString(t) {
+			// PROB30 TEMPO is a single group.
+			if t == "TEMPO" && cur.Change == "PROB" && len(body) == 1 {
+				body = append(body, t)
+				continue
+			}
+			flush()
+			cur = &ForecastGroup{Change: t}
+			if m := reProb.FindStringSubmatch(t); m != nil {
+				cur.Change = "PROB"
+				cur.Probability, _ = strconv.Atoi(m[1])
+			}
+			body = []string{t}
+			continue
+		}
+		if len(body) > 0 && cur.Change != "" && cur.Change != "FM" && cur.From == nil {
+			if from, to, ok := parseTAFPeriod(t); ok {
+				cur.From, cur.To = from, to
+				body = append(body, t)
+				continue
+			}
+		}
+		body = append(body, t)
+	}
+	flush()
+
+	// The base forecast and each FM group run until the next FM group.
+	prev := 0
+	for gi := 1; gi < len(groups); gi++ {
+		if groups[gi].Change == "FM" {
+			groups[prev].To = groups[gi].From
+			prev = gi
+		}
+	}
+	groups[prev].To = taf.ValidTo
+
+	taf.Groups = groups
+	return taf, nil
+}
+
+// parseBody fills the group's conditions from its tokens. Change-group
+// keywords and periods are skipped.
+func (g *ForecastGroup) parseBody(tokens []string) {
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		if reTAFFrom.MatchString(t) || reTAFPeriod.MatchString(t) || reProb.MatchString(t) || t == "BECMG" || t == "TEMPO" {
+			continue
+		}
+		if reTrendTime.MatchString(t) {
+			g.TrendTimes = append(g.TrendTimes, t)
+			continue
+		}
+		if t == "CAVOK" {
+			g.CAVOK = true
+			continue
+		}
+		if m := reWindShear.FindStringSubmatch(t); m != nil {
+			if w, ok := parseWind(m[2]); ok {
+				h, _ := strconv.Atoi(m[1])
+				g.WindShear = &WindShear{Height: h * 100, Wind: *w}
+				continue
+			}
+		}
+		if g.Wind == nil {
+			if w, ok := parseWind(t); ok {
+				g.Wind = w
+				if i+1 < len(tokens) {
+					if v, ok := parseWindVariation(tokens[i+1]); ok {
+						w.Variation = v
+						i++
+					}
+				}
+				continue
+			}
+		}
+		if g.Visibility == nil {
+			if vis, used := parseVisibility(tokens[i:]); used > 0 {
+				g.Visibility = vis
+				i += used - 1
+				continue
+			}
+		}
+		if t == "NSW" {
+			g.NoSigWeather = true
+			continue
+		}
+		if isSkyToken(t) {
+			g.Sky = append(g.Sky, parseSkyLayer(t))
+			continue
+		}
+		if wx := parseWeatherGroup(t); isKnownWeather(wx) {
+			g.Weather = append(g.Weather, wx)
+			continue
+		}
+		g.Other = append(g.Other, t)
+	}
+}
+
+// parseTrendGroup parses an ICAO METAR trend forecast such as
+// "BECMG FM1030 TL1100 25015KT" or "TEMPO 4000 SHRA".
+func parseTrendGroup(tokens []string) ForecastGroup {
+	g := ForecastGroup{Change: tokens[0], Raw: strings.Join(tokens, " ")}
+	g.parseBody(tokens)
+	return g
+}
+
+// parseTAFPeriod parses a DDHH/DDHH validity or change period.
+func parseTAFPeriod(t string) (from, to *TAFTime, ok bool) {
+	m := reTAFPeriod.FindStringSubmatch(t)
+	if m == nil {
+		return nil, nil, false
+	}
+	n := make([]int, 4)
+	for k := range n {
+		n[k], _ = strconv.Atoi(m[k+1])
+	}
+	return &TAFTime{Day: n[0], Hour: n[1]}, &TAFTime{Day: n[2], Hour: n[3]}, true
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTAF(t *testing.T) {
+	tests := []struct {
+		name       string
+		raw        string
+		station    string
+		amended    bool
+		validFrom  *TAFTime
+		validTo    *TAFTime
+		noForecast bool
+		cancelled  bool
+		changes    []string // Change of each group
+	}{
+		{
+			name:       "NIL without a validity period",
+			raw:        "TAF KXYZ 151720Z NIL=",
+			station:    "KXYZ",
+			noForecast: true,
+		},
+		{
+			name:      "CNL without a validity period",
+			raw:       "TAF AMD KXYZ 151720Z CNL",
+			station:   "KXYZ",
+			amended:   true,
+			cancelled: true,
+		},
+		{
+			name:      "CNL with a validity period",
+			raw:       "TAF AMD KXYZ 151720Z 1518/1618 CNL",
+			station:   "KXYZ",
+			amended:   true,
+			validFrom: &TAFTime{Day: 15, Hour: 18},
+			validTo:   &TAFTime{Day: 16, Hour: 18},
+			cancelled: true,
+			changes:   []string{""},
+		},
+		{
+			name: "change groups",
+			raw: "TAF KTYS 151720Z 1518/1618 21010KT P6SM SCT050 " +
+				"FM152000 22012G20KT P6SM BKN050 WS020/24045KT " +
+				"TEMPO 1520/1524 3SM TSRA BKN030CB " +
+				"PROB30 1602/1606 1SM BR OVC004 " +
+				"BECMG 1612/1614 VRB03KT",
+			station:   "KTYS",
+			validFrom: &TAFTime{Day: 15, Hour: 18},
+			validTo:   &TAFTime{Day: 16, Hour: 18},
+			changes:   []string{"", "FM", "TEMPO", "PROB", "BECMG"},
+		},
+		{
+			name:      "period ending at hour 24",
+			raw:       "TAF KTYS 151720Z 1518/1524 21010KT P6SM SCT050",
+			station:   "KTYS",
+			validFrom: &TAFTime{Day: 15, Hour: 18},
+			validTo:   &TAFTime{Day: 15, Hour: 24},
+			changes:   []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taf, err := ParseTAF(tt.raw)
+			if err != nil {
+				t.Fatalf("ParseTAF(%q): %v", tt.raw, err)
+			}
+			if taf.Station != tt.station {
+				t.Errorf("Station = %q, want %q", taf.Station, tt.station)
+			}
+			if taf.Amended != tt.amended {
+				t.Errorf("Amended = %v, want %v", taf.Amended, tt.amended)
+			}
+			if !reflect.DeepEqual(taf.ValidFrom, tt.validFrom) || !reflect.DeepEqual(taf.ValidTo, tt.validTo) {
+				t.Errorf("valid %v to %v, want %v to %v", taf.ValidFrom, taf.ValidTo, tt.validFrom, tt.validTo)
+			}
+			if taf.NoForecast != tt.noForecast {
+				t.Errorf("NoForecast = %v, want %v", taf.NoForecast, tt.noForecast)
+			}
+			if taf.Cancelled != tt.cancelled {
+				t.Errorf("Cancelled = %v, want %v", taf.Cancelled, tt.cancelled)
+			}
+			var changes []string
+			for _, g := range taf.Groups {
+				changes = append(changes, g.Change)
+			}
+			if !reflect.DeepEqual(changes, tt.changes) {
+				t.Errorf("group changes = %q, want %q", changes, tt.changes)
+			}
+		})
+	}
+}
+
+func TestParseTAFErrors(t *testing.T) {
+	for _, raw := range []string{
+		"",
+		"TAF",
+		"TAF KXYZ 151720Z 21010KT P6SM SCT050",
+	} {
+		if _, err := ParseTAF(raw); err == nil {
+			t.Errorf("ParseTAF(%q): no error", raw)
+		}
+	}
+}
+
+func TestParseTAFsKeepsTheOthers(t *testing.T) {
+	in := strings.Join([]string{
+		"TAF KXYZ 151720Z NIL=",
+		"TAF KBAD 151720Z 21010KT P6SM",
+		"TAF KTYS 151720Z 1518/1618 21010KT P6SM SCT050",
+	}, "\n")
+	tafs, err := parseTAFs(in)
+	if err == nil || !strings.Contains(err.Error(), "KBAD") {
+		t.Errorf("error = %v, want one naming KBAD", err)
+	}
+	var stations []string
+	for _, taf := range tafs {
+		stations = append(stations, taf.Station)
+	}
+	if want := []string{"KXYZ", "KTYS"}; !reflect.DeepEqual(stations, want) {
+		t.Errorf("parsed %q, want %q", stations, want)
+	}
+}
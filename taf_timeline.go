@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TimelineHour is the forecast resolved for one hour of a TAF's validity
+// period: the prevailing conditions after applying FM and BECMG groups, and
+// any TEMPO, PROB or in-progress BECMG conditions that may occur instead.
+type TimelineHour struct {
+	Start      time.Time
+	Prevailing Conditions
+	Category   string
+	Possible   []PossibleConditions
+}
+
+// PossibleConditions are conditions a change group allows for during an hour.
+type PossibleConditions struct {
+	Source     string // TEMPO, PROB30, PROB40 TEMPO, BECMG, ...
+	Conditions Conditions
+	Category   string
+}
+
+// Timeline resolves the TAF into one entry per hour of its validity period.
+// ref anchors the day-of-month groups to a real month and year; pass the
+// current time for a live TAF.
+func (t *TAF) Timeline(ref time.Time) []TimelineHour {
+	if len(t.Groups) == 0 || t.ValidFrom == nil {
+		return nil
+	}
+	validFrom := resolveTAFTime(ref, t.ValidFrom)
+	validTo := resolveTAFTime(validFrom, t.ValidTo)
+
+	type span struct {
+		from, to time.Time
+		g        ForecastGroup
+	}
+	spans := make([]span, len(t.Groups))
+	for i, g := range t.Groups {
+		s := span{from: validFrom, to: validTo, g: g}
+		if g.From != nil {
+			s.from = resolveTAFTime(validFrom, g.From)
+		}
+		if g.To != nil {
+			s.to = resolveTAFTime(s.from, g.To)
+		}
+		spans[i] = s
+	}
+
+	var hours []TimelineHour
+	for h := validFrom; h.Before(validTo); h = h.Add(time.Hour) {
+		prevailing := spans[0].g.Conditions
+		var possible []PossibleConditions
+
+		for _, s := range spans[1:] {
+			switch s.g.Change {
+			case "FM":
+				if !h.Before(s.from) {
+					prevailing = s.g.Conditions
+				}
+			case "BECMG":
+				if !h.Before(s.to) {
+					prevailing = applyChangeGroup(prevailing, s.g)
+				} else if !h.Before(s.from) {
+					possible = append(possible, PossibleConditions{Source: "BECMG", Conditions: applyChangeGroup(prevailing, s.g)})
+				}
+			case "TEMPO", "PROB":
+				if !h.Before(s.from) && h.Before(s.to) {
+					possible = append(possible, PossibleConditions{Source: changeGroupLabel(s.g), Conditions: applyChangeGroup(prevailing, s.g)})
+				}
+			}
+		}
+
+		for i := range possible {
+			possible[i].Category = possible[i].Conditions.FlightCategory()
+		}
+		hours = append(hours, TimelineHour{
+			Start:      h,
+			Prevailing: prevailing,
+			Category:   prevailing.FlightCategory(),
+			Possible:   possible,
+		})
+	}
+	return hours
+}
+
+// applyChangeGroup overlays the elements a BECMG/TEMPO/PROB group forecasts
+// onto base; elements the group does not mention carry over.
+func applyChangeGroup(base Conditions, g ForecastGroup) Conditions {
+	out := base
+	if g.Wind != nil {
+		out.Wind = g.Wind
+	}
+	if g.CAVOK {
+		out.CAVOK = true
+		out.Visibility = nil
+		out.Weather = nil
+		out.Sky = nil
+	}
+	if g.Visibility != nil {
+		out.Visibility = g.Visibility
+		out.CAVOK = false
+	}
+	if len(g.Weather) > 0 {
+		out.Weather = g.Weather
+	}
+	if g.NoSigWeather {
+		out.Weather = nil
+	}
+	if len(g.Sky) > 0 {
+		out.Sky = g.Sky
+		out.CAVOK = false
+	}
+	return out
+}
+
+func changeGroupLabel(g ForecastGroup) string {
+	if g.Change != "PROB" {
+		return g.Change
+	}
+	label := fmt.Sprintf("PROB%d", g.Probability)
+	if strings.Contains(g.Raw, "TEMPO") {
+		label += " TEMPO"
+	}
+	return label
+}
+
+func resolveTAFTime(ref time.Time, t *TAFTime) time.Time {
+	return resolveDayTime(ref, t.Day, t.Hour, t.Minute)
+}
+
+func printTAFTimeline(t *TAF, ref time.Time, u displayUnits) {
+	switch {
+	case t.Cancelled:
+		fmt.Printf("%s TAF cancelled\n", t.Station)
+		return
+	case t.NoForecast:
+		fmt.Printf("%s TAF: no forecast issued (NIL)\n", t.Station)
+		return
+	}
+	fmt.Printf("%s TAF timeline, valid %s to %s\n", t.Station, t.ValidFrom, t.ValidTo)
+	for _, h := range t.Timeline(ref) {
+		fmt.Printf("%s  %-4s  %s\n", h.Start.Format("02/1504Z"), h.Category, summarizeConditions(h.Prevailing, u))
+		for _, p := range h.Possible {
+			fmt.Printf("%8s  %-4s  %s: %s\n", "", p.Category, p.Source, summarizeConditions(p.Conditions, u))
+		}
+	}
+}
+
+// summarizeConditions renders conditions on one line for the timeline.
+func summarizeConditions(c Conditions, u displayUnits) string {
+	var parts []string
+	if c.Wind != nil {
+		parts = append(parts, "Wind "+describeWind(c.Wind, u))
+	}
+	if c.Visibility != nil {
+		parts = append(parts, "Visibility "+describeVisibility(c.Visibility, u))
+	}
+	if c.CAVOK {
+		parts = append(parts, "CAVOK")
+	}
+	if len(c.Weather) > 0 {
+		var wx []string
+		for _, w := range c.Weather {
+			wx = append(wx, decodeWxToken(w.Raw))
+		}
+		parts = append(parts, strings.Join(wx, ", "))
+	}
+	if ceiling := c.Ceiling(); ceiling != nil {
+		parts = append(parts, "Ceiling "+u.height(*ceiling, "ft"))
+	} else {
+		parts = append(parts, "No ceiling")
+	}
+	return strings.Join(parts, "; ")
+}
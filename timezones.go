@@ -0,0 +1,148 @@
+package main
+
+// The IANA database is embedded so local times work on systems without one
+// (Windows, minimal containers).
+import _ "time/tzdata"
+
+// Station time zones come from the bundled identifier table, which gives
+// each airport its own zone. Stations it does not list are looked up by
+// country and, for the US and Canada, by state or province; states that span
+// two zones are split by longitude, which is only approximate near the zone
+// boundaries.
+
+// zoneSplit assigns the zone West to stations west of Lon and the state's
+// default zone to the rest.
+type zoneSplit struct {
+	Lon  float64
+	West string
+}
+
+type stateZone struct {
+	Zone  string
+	Split *zoneSplit
+}
+
+var usStateZones = map[string]stateZone{
+	"AL": {Zone: "America/Chicago"},
+	"AK": {Zone: "America/Anchorage", Split: &zoneSplit{Lon: -169.5, West: "America/Adak"}},
+	"AZ": {Zone: "America/Phoenix"},
+	"AR": {Zone: "America/Chicago"},
+	"CA": {Zone: "America/Los_Angeles"},
+	"CO": {Zone: "America/Denver"},
+	"CT": {Zone: "America/New_York"},
+	"DE": {Zone: "America/New_York"},
+	"DC": {Zone: "America/New_York"},
+	"FL": {Zone: "America/New_York", Split: &zoneSplit{Lon: -85.0, West: "America/Chicago"}},
+	"GA": {Zone: "America/New_York"},
+	"HI": {Zone: "Pacific/Honolulu"},
+	"ID": {Zone: "America/Boise"},
+	"IL": {Zone: "America/Chicago"},
+	"IN": {Zone: "America/Indiana/Indianapolis", Split: &zoneSplit{Lon: -87.1, West: "America/Chicago"}},
+	"IA": {Zone: "America/Chicago"},
+	"KS": {Zone: "America/Chicago", Split: &zoneSplit{Lon: -101.5, West: "America/Denver"}},
+	"KY": {Zone: "America/New_York", Split: &zoneSplit{Lon: -86.0, West: "America/Chicago"}},
+	"LA": {Zone: "America/Chicago"},
+	"ME": {Zone: "America/New_York"},
+	"MD": {Zone: "America/New_York"},
+	"MA": {Zone: "America/New_York"},
+	"MI": {Zone: "America/Detroit", Split: &zoneSplit{Lon: -87.6, West: "America/Menominee"}},
+	"MN": {Zone: "America/Chicago"},
+	"MS": {Zone: "America/Chicago"},
+	"MO": {Zone: "America/Chicago"},
+	"MT": {Zone: "America/Denver"},
+	"NE": {Zone: "America/Chicago", Split: &zoneSplit{Lon: -101.3, West: "America/Denver"}},
+	"NV": {Zone: "America/Los_Angeles"},
+	"NH": {Zone: "America/New_York"},
+	"NJ": {Zone: "America/New_York"},
+	"NM": {Zone: "America/Denver"},
+	"NY": {Zone: "America/New_York"},
+	"NC": {Zone: "America/New_York"},
+	"ND": {Zone: "America/Chicago", Split: &zoneSplit{Lon: -101.5, West: "America/Denver"}},
+	"OH": {Zone: "America/New_York"},
+	"OK": {Zone: "America/Chicago"},
+	"OR": {Zone: "America/Los_Angeles"},
+	"PA": {Zone: "America/New_York"},
+	"RI": {Zone: "America/New_York"},
+	"SC": {Zone: "America/New_York"},
+	"SD": {Zone: "America/Chicago", Split: &zoneSplit{Lon: -100.5, West: "America/Denver"}},
+	"TN": {Zone: "America/New_York", Split: &zoneSplit{Lon: -85.1, West: "America/Chicago"}},
+	"TX": {Zone: "America/Chicago", Split: &zoneSplit{Lon: -104.9, West: "America/Denver"}},
+	"UT": {Zone: "America/Denver"},
+	"VT": {Zone: "America/New_York"},
+	"VA": {Zone: "America/New_York"},
+	"WA": {Zone: "America/Los_Angeles"},
+	"WV": {Zone: "America/New_York"},
+	"WI": {Zone: "America/Chicago"},
+	"WY": {Zone: "America/Denver"},
+	"PR": {Zone: "America/Puerto_Rico"},
+	"VI": {Zone: "America/St_Thomas"},
+	"GU": {Zone: "Pacific/Guam"},
+	"MP": {Zone: "Pacific/Saipan"},
+	"AS": {Zone: "Pacific/Pago_Pago"},
+}
+
+var canadaProvinceZones = map[string]stateZone{
+	"AB": {Zone: "America/Edmonton"},
+	"BC": {Zone: "America/Vancouver"},
+	"MB": {Zone: "America/Winnipeg"},
+	"NB": {Zone: "America/Moncton"},
+	"NL": {Zone: "America/St_Johns", Split: &zoneSplit{Lon: -59.0, West: "America/Goose_Bay"}},
+	"NS": {Zone: "America/Halifax"},
+	"NT": {Zone: "America/Yellowknife"},
+	"NU": {Zone: "America/Iqaluit", Split: &zoneSplit{Lon: -85.0, West: "America/Rankin_Inlet"}},
+	"ON": {Zone: "America/Toronto", Split: &zoneSplit{Lon: -90.0, West: "America/Winnipeg"}},
+	"PE": {Zone: "America/Halifax"},
+	"QC": {Zone: "America/Toronto"},
+	"SK": {Zone: "America/Regina"},
+	"YT": {Zone: "America/Whitehorse"},
+}
+
+// countryZones covers countries that use a single zone, or whose aviation
+// weather stations mostly do.
+var countryZones = map[string]string{
+	"AE": "Asia/Dubai", "AR": "America/Argentina/Buenos_Aires", "AT": "Europe/Vienna",
+	"BE": "Europe/Brussels", "BM": "Atlantic/Bermuda", "BS": "America/Nassau",
+	"CH": "Europe/Zurich", "CN": "Asia/Shanghai", "CO": "America/Bogota",
+	"CZ": "Europe/Prague", "DE": "Europe/Berlin", "DK": "Europe/Copenhagen",
+	"DO": "America/Santo_Domingo", "EG": "Africa/Cairo", "ES": "Europe/Madrid",
+	"FI": "Europe/Helsinki", "FR": "Europe/Paris", "GB": "Europe/London",
+	"GR": "Europe/Athens", "HK": "Asia/Hong_Kong", "HU": "Europe/Budapest",
+	"IE": "Europe/Dublin", "IL": "Asia/Jerusalem", "IN": "Asia/Kolkata",
+	"IS": "Atlantic/Reykjavik", "IT": "Europe/Rome", "JM": "America/Jamaica",
+	"JP": "Asia/Tokyo", "KR": "Asia/Seoul", "NL": "Europe/Amsterdam",
+	"NO": "Europe/Oslo", "NZ": "Pacific/Auckland", "PA": "America/Panama",
+	"PH": "Asia/Manila", "PL": "Europe/Warsaw", "PT": "Europe/Lisbon",
+	"QA": "Asia/Qatar", "SE": "Europe/Stockholm", "SG": "Asia/Singapore",
+	"TH": "Asia/Bangkok", "TR": "Europe/Istanbul", "TW": "Asia/Taipei",
+	"ZA": "Africa/Johannesburg",
+}
+
+// stationTimeZone returns the IANA time zone for a station, or "" when it
+// is not known.
+func stationTimeZone(icao, country, state string, lon float64) string {
+	for _, a := range airportIDs() {
+		if a.ICAO == icao && a.TimeZone != "" {
+			return a.TimeZone
+		}
+	}
+	var zones map[string]stateZone
+	switch country {
+	case "US":
+		zones = usStateZones
+	case "CA":
+		zones = canadaProvinceZones
+	default:
+		return countryZones[country]
+	}
+	z, ok := zones[state]
+	if !ok {
+		return ""
+	}
+	if state == "AK" && lon > 0 {
+		lon -= 360 // the western Aleutians lie past the antimeridian
+	}
+	if z.Split != nil && lon < z.Split.Lon {
+		return z.Split.West
+	}
+	return z.Zone
+}
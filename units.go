@@ -0,0 +1,232 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// displayUnits selects the units decoded values are shown in. An empty field
+// keeps whatever unit the report itself used, which is the default.
+type displayUnits struct {
+	Wind       string // kt, mph, kmh, mps
+	Visibility string // SM, km, m
+	Height     string // ft, m
+	Temp       string // C, F
+	Pressure   string // inHg, hPa
+	Precip     string // in, mm
+}
+
+// unitPresets are the named systems accepted by --units.
+var unitPresets = map[string]displayUnits{
+	"aviation": {Wind: "kt", Visibility: "SM", Height: "ft", Temp: "C", Pressure: "inHg", Precip: "in"},
+	"us":       {Wind: "mph", Visibility: "SM", Height: "ft", Temp: "F", Pressure: "inHg", Precip: "in"},
+	"metric":   {Wind: "kmh", Visibility: "km", Height: "m", Temp: "C", Pressure: "hPa", Precip: "mm"},
+	"si":       {Wind: "mps", Visibility: "m", Height: "m", Temp: "C", Pressure: "hPa", Precip: "mm"},
+}
+
+// unitChoices lists the accepted values for each --units key, mapping every
+// accepted spelling to its canonical name.
+var unitChoices = map[string]map[string]string{
+	"wind":     {"kt": "kt", "kts": "kt", "mph": "mph", "kmh": "kmh", "km/h": "kmh", "mps": "mps", "m/s": "mps"},
+	"vis":      {"sm": "SM", "mi": "SM", "km": "km", "m": "m"},
+	"height":   {"ft": "ft", "m": "m"},
+	"temp":     {"c": "C", "f": "F"},
+	"pressure": {"inhg": "inHg", "hpa": "hPa", "mb": "hPa"},
+	"precip":   {"in": "in", "mm": "mm"},
+}
+
+// parseUnits parses a --units value: a comma-separated list of preset names
+// (aviation, us, metric, si) and key=unit overrides (wind, vis, height, temp,
+// pressure, precip), applied left to right. An empty spec keeps the reported
+// units.
+func parseUnits(spec string) (displayUnits, error) {
+	var u displayUnits
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(item, "=")
+		if !ok {
+			p, found := unitPresets[item]
+			if !found {
+				return u, fmt.Errorf("unknown unit system %q (supported: aviation, us, metric, si)", item)
+			}
+			u = p
+			continue
+		}
+		if key == "visibility" {
+			key = "vis"
+		}
+		choices, found := unitChoices[key]
+		if !found {
+			return u, fmt.Errorf("unknown unit quantity %q (supported: wind, vis, height, temp, pressure, precip)", key)
+		}
+		canonical, found := choices[strings.TrimSpace(value)]
+		if !found {
+			return u, fmt.Errorf("unsupported %s unit %q", key, value)
+		}
+		switch key {
+		case "wind":
+			u.Wind = canonical
+		case "vis":
+			u.Visibility = canonical
+		case "height":
+			u.Height = canonical
+		case "temp":
+			u.Temp = canonical
+		case "pressure":
+			u.Pressure = canonical
+		case "precip":
+			u.Precip = canonical
+		}
+	}
+	return u, nil
+}
+
+const (
+	metersPerFoot = 0.3048
+	hPaPerInHg    = 33.8639
+	mmPerInch     = 25.4
+)
+
+// speedLabels maps both METAR (KT, MPS, KMH) and --units spellings to the
+// label printed after a speed.
+var speedLabels = map[string]string{
+	"KT": "kt", "kt": "kt",
+	"MPS": "m/s", "mps": "m/s",
+	"KMH": "km/h", "kmh": "km/h",
+	"mph": "mph",
+}
+
+// metersPerSecond is the size of one unit of each wind speed unit in m/s.
+var metersPerSecond = map[string]float64{
+	"KT": 1852.0 / 3600, "kt": 1852.0 / 3600,
+	"MPS": 1, "mps": 1,
+	"KMH": 1000.0 / 3600, "kmh": 1000.0 / 3600,
+	"mph": metersPerStatuteMile / 3600,
+}
+
+// speed formats a wind speed reported in unit (KT, MPS or KMH). Reported
+// values keep the report's two-digit form, e.g. "07 kt".
+func (u displayUnits) speed(v int, unit string) string {
+	if unit == "" {
+		unit = "KT"
+	}
+	to := u.Wind
+	if to == "" || speedLabels[to] == speedLabels[unit] {
+		return fmt.Sprintf("%02d %s", v, speedLabels[unit])
+	}
+	conv := float64(v) * metersPerSecond[unit] / metersPerSecond[to]
+	return fmt.Sprintf("%d %s", int(math.Round(conv)), speedLabels[to])
+}
+
+// height formats a height or distance reported in feet ("ft") or meters
+// ("m"), e.g. cloud bases, vertical visibility and RVR.
+func (u displayUnits) height(v int, unit string) string {
+	to := u.Height
+	if to == "" || to == unit {
+		return fmt.Sprintf("%d %s", v, unit)
+	}
+	if to == "m" {
+		return fmt.Sprintf("%d m", int(math.Round(float64(v)*metersPerFoot)))
+	}
+	return fmt.Sprintf("%d ft", int(math.Round(float64(v)/metersPerFoot)))
+}
+
+// visibilityIn converts a visibility to the selected unit, returning false
+// when the reported unit should be kept.
+func (u displayUnits) visibilityIn(value float64, unit string) (float64, string, bool) {
+	to := u.Visibility
+	if to == "" || to == unit {
+		return value, unit, false
+	}
+	meters := value
+	if unit == "SM" {
+		meters = value * metersPerStatuteMile
+	}
+	switch to {
+	case "SM":
+		return math.Round(meters/metersPerStatuteMile*4) / 4, "SM", true
+	case "km":
+		return meters / 1000, "km", true
+	default:
+		return math.Round(meters), "m", true
+	}
+}
+
+// visibilityText formats a converted visibility value for display.
+func visibilityText(value float64, unit string) string {
+	switch unit {
+	case "SM":
+		return formatFraction(value) + " statute miles"
+	case "km":
+		return fmt.Sprintf("%.1f km", value)
+	default:
+		return fmt.Sprintf("%.0f m", value)
+	}
+}
+
+// tempInt formats a whole-degree Celsius temperature; reported values keep
+// the report's two-digit form, e.g. "-02°C". A nil value prints as "?".
+func (u displayUnits) tempInt(c *int) string {
+	if u.Temp == "F" {
+		if c == nil {
+			return "?°F"
+		}
+		return fmt.Sprintf("%.0f°F", celsiusToFahrenheit(float64(*c)))
+	}
+	return formatMInt(c) + "°C"
+}
+
+// tempTenths formats a Celsius temperature given to a tenth of a degree.
+func (u displayUnits) tempTenths(c float64) string {
+	if u.Temp == "F" {
+		return fmt.Sprintf("%.1f°F", celsiusToFahrenheit(c))
+	}
+	return fmt.Sprintf("%.1f°C", c)
+}
+
+// tempDiff formats a difference of two Celsius temperatures.
+func (u displayUnits) tempDiff(c float64) string {
+	if u.Temp == "F" {
+		return fmt.Sprintf("%.1f°F", c*9/5)
+	}
+	return fmt.Sprintf("%.1f°C", c)
+}
+
+func celsiusToFahrenheit(c float64) float64 {
+	return c*9/5 + 32
+}
+
+// pressure formats a pressure in unit (inHg or hPa). precise selects tenths
+// of a hectopascal, as used by sea-level pressure and tendencies.
+func (u displayUnits) pressure(v float64, unit string, precise bool) string {
+	to := u.Pressure
+	if to == "" {
+		to = unit
+	}
+	switch {
+	case to == unit:
+	case to == "hPa":
+		v *= hPaPerInHg
+	default:
+		v /= hPaPerInHg
+	}
+	if to == "inHg" {
+		return fmt.Sprintf("%.2f inHg", v)
+	}
+	if precise {
+		return fmt.Sprintf("%.1f hPa", v)
+	}
+	return fmt.Sprintf("%.0f hPa", v)
+}
+
+// precip formats a precipitation amount reported in inches.
+func (u displayUnits) precip(in float64) string {
+	if u.Precip == "mm" {
+		return fmt.Sprintf("%.1f mm", in*mmPerInch)
+	}
+	return fmt.Sprintf("%.2f in", in)
+}
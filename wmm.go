@@ -0,0 +1,241 @@
+package main
+
+import (
+	_ "embed"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// wmmCOF is the World Magnetic Model coefficient file, in the format NOAA
+// publishes it (WMM.COF). A new model is a drop-in replacement of the file.
+//
+//go:embed wmm.cof
+var wmmCOF string
+
+const wmmDegree = 12
+
+// wmmModel holds the Gauss coefficients (nT) and their secular variation
+// (nT/year), indexed [n][m].
+type wmmModel struct {
+	Name             string
+	Epoch            float64 // decimal year
+	G, H, GDot, HDot [wmmDegree + 1][wmmDegree + 1]float64
+}
+
+// wmmSpan is how long a model is valid from its epoch, in years.
+const wmmSpan = 5
+
+var wmm = sync.OnceValue(func() wmmModel {
+	m, err := parseWMM(wmmCOF)
+	if err != nil {
+		panic(fmt.Sprintf("wmm.cof: %v", err))
+	}
+	return m
+})
+
+func parseWMM(s string) (wmmModel, error) {
+	var m wmmModel
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	head := strings.Fields(lines[0])
+	if len(head) < 2 {
+		return m, fmt.Errorf("bad header %q", lines[0])
+	}
+	epoch, err := strconv.ParseFloat(head[0], 64)
+	if err != nil {
+		return m, fmt.Errorf("bad epoch %q", head[0])
+	}
+	m.Name, m.Epoch = head[1], epoch
+	for _, ln := range lines[1:] {
+		f := strings.Fields(ln)
+		if len(f) == 1 && strings.HasPrefix(f[0], "9999") {
+			break
+		}
+		if len(f) != 6 {
+			return m, fmt.Errorf("bad line %q", ln)
+		}
+		var v [6]float64
+		for i, s := range f {
+			if v[i], err = strconv.ParseFloat(s, 64); err != nil {
+				return m, fmt.Errorf("bad line %q", ln)
+			}
+		}
+		n, k := int(v[0]), int(v[1])
+		if n < 1 || n > wmmDegree || k < 0 || k > n {
+			return m, fmt.Errorf("bad degree/order in %q", ln)
+		}
+		m.G[n][k], m.H[n][k], m.GDot[n][k], m.HDot[n][k] = v[2], v[3], v[4], v[5]
+	}
+	return m, nil
+}
+
+// covers reports whether t falls within the years the model is valid for.
+func (m wmmModel) covers(t time.Time) bool {
+	y := decimalYear(t)
+	return y >= m.Epoch && y < m.Epoch+wmmSpan
+}
+
+// warnWMMExpired reports once that the model does not cover a report date.
+var warnWMMExpired sync.Once
+
+// decimalYear converts t to a year with a fraction, e.g. 2026.79.
+func decimalYear(t time.Time) float64 {
+	t = t.UTC()
+	start := time.Date(t.Year(), 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	return float64(t.Year()) + float64(t.Sub(start))/float64(end.Sub(start))
+}
+
+// magneticDeclination returns the magnetic variation in degrees, east
+// positive, at p and altitude altKm above the WGS 84 ellipsoid on date t,
+// as the WMM computes it.
+func magneticDeclination(p latLon, altKm float64, t time.Time) float64 {
+	const (
+		wgsA   = 6378.137          // semi-major axis, km
+		wgsF   = 1 / 298.257223563 // flattening
+		refRad = 6371.2            // geomagnetic reference radius, km
+	)
+	m := wmm()
+	dt := decimalYear(t) - m.Epoch
+
+	// Geodetic to geocentric spherical coordinates.
+	e2 := wgsF * (2 - wgsF)
+	lat, lon := radians(p.Lat), radians(p.Lon)
+	rc := wgsA / math.Sqrt(1-e2*math.Sin(lat)*math.Sin(lat))
+	xy := (rc + altKm) * math.Cos(lat)
+	z := (rc*(1-e2) + altKm) * math.Sin(lat)
+	r := math.Hypot(xy, z)
+	latC := math.Asin(z / r)
+
+	// Schmidt semi-normalised associated Legendre functions of sin(latC)
+	// and their derivatives with respect to latitude.
+	x, c := math.Sin(latC), math.Cos(latC)
+	var pnm, dpnm [wmmDegree + 1][wmmDegree + 1]float64
+	for k := 0; k <= wmmDegree; k++ {
+		pkk := 1.0 // unnormalised P_k^k = (2k-1)!! c^k
+		for i := 1; i <= k; i++ {
+			pkk *= float64(2*i-1) * c
+		}
+		pnm[k][k] = pkk
+		if k < wmmDegree {
+			pnm[k+1][k] = x * float64(2*k+1) * pkk
+		}
+		for n := k + 2; n <= wmmDegree; n++ {
+			pnm[n][k] = (float64(2*n-1)*x*pnm[n-1][k] - float64(n+k-1)*pnm[n-2][k]) / float64(n-k)
+		}
+	}
+	for n := 1; n <= wmmDegree; n++ {
+		for k := 0; k <= n; k++ {
+			// d/dlat P_n^k(sin lat) = ((n+k) P_(n-1)^k - n x P_n^k) / cos lat
+			prev := 0.0
+			if n-1 >= k {
+				prev = pnm[n-1][k]
+			}
+			dpnm[n][k] = (float64(n+k)*prev - float64(n)*x*pnm[n][k]) / c
+		}
+	}
+	for n := 1; n <= wmmDegree; n++ {
+		for k := 0; k <= n; k++ {
+			norm := math.Sqrt(factorialRatio(n-k, n+k))
+			if k > 0 {
+				norm *= math.Sqrt2
+			}
+			pnm[n][k] *= norm
+			dpnm[n][k] *= norm
+		}
+	}
+
+	// Field components in the geocentric frame: north, east, down.
+	var bx, by, bz float64
+	for n := 1; n <= wmmDegree; n++ {
+		ratio := math.Pow(refRad/r, float64(n+2))
+		for k := 0; k <= n; k++ {
+			g := m.G[n][k] + dt*m.GDot[n][k]
+			h := m.H[n][k] + dt*m.HDot[n][k]
+			cosK, sinK := math.Cos(float64(k)*lon), math.Sin(float64(k)*lon)
+			bx -= ratio * (g*cosK + h*sinK) * dpnm[n][k]
+			by += ratio * float64(k) * (g*sinK - h*cosK) * pnm[n][k]
+			bz -= ratio * float64(n+1) * (g*cosK + h*sinK) * pnm[n][k]
+		}
+	}
+	by /= c
+
+	// Rotate north into the geodetic frame; east is unchanged.
+	psi := latC - lat
+	north := bx*math.Cos(psi) - bz*math.Sin(psi)
+	return degrees(math.Atan2(by, north))
+}
+
+// factorialRatio is a!/b! for a <= b.
+func factorialRatio(a, b int) float64 {
+	v := 1.0
+	for i := a + 1; i <= b; i++ {
+		v /= float64(i)
+	}
+	return v
+}
+
+// describeVariation renders a magnetic variation, e.g. "6.1° W".
+func describeVariation(deg float64) string {
+	deg = math.Round(deg*10)/10 + 0 // no "-0.0"
+	if deg < 0 {
+		return fmt.Sprintf("%.1f° W", -deg)
+	}
+	return fmt.Sprintf("%.1f° E", deg)
+}
+
+// magneticVariation is the variation at the station on the day of the
+// report, east positive. It needs the station position, which JSON input
+// carries and raw text gets from --enrich, and a model valid on that day;
+// outside the model's span it warns once and reports no variation rather
+// than an extrapolated one.
+func magneticVariation(o *Observation) (float64, bool) {
+	if o.Info == nil {
+		return 0, false
+	}
+	t := o.ObservedAt
+	if t.IsZero() {
+		t = time.Now()
+	}
+	if m := wmm(); !m.covers(t) {
+		warnWMMExpired.Do(func() {
+			fmt.Fprintf(os.Stderr, "WARNING: the bundled magnetic model %s is valid %.0f to %.0f, not on %s; magnetic directions are left out (replace wmm.cof with NOAA's current WMM.COF and rebuild)\n",
+				m.Name, m.Epoch, m.Epoch+wmmSpan, t.UTC().Format("2006-01-02"))
+		})
+		return 0, false
+	}
+	return magneticDeclination(latLon{Lat: o.Info.Lat, Lon: o.Info.Lon}, o.Info.Elev/1000, t), true
+}
+
+// magneticWindDirection is the reported wind direction in degrees magnetic,
+// 1-360, when the wind has a direction and the variation is known.
+func magneticWindDirection(o *Observation) (int, bool) {
+	w := o.Wind
+	if w == nil || w.Variable || w.Calm() {
+		return 0, false
+	}
+	v, ok := magneticVariation(o)
+	if !ok {
+		return 0, false
+	}
+	d := int(math.Round(float64(w.Direction)-v)) % 360
+	if d <= 0 {
+		d += 360
+	}
+	return d, true
+}
+
+// describeMagneticWind follows the decoded wind, e.g. "(216° magnetic,
+// variation 6.1° W)", or is empty when the magnetic direction is unknown.
+func describeMagneticWind(o *Observation) string {
+	d, ok := magneticWindDirection(o)
+	if !ok {
+		return ""
+	}
+	v, _ := magneticVariation(o)
+	return fmt.Sprintf(" (%03d° magnetic, variation %s)", d, describeVariation(v))
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// TestMagneticDeclination checks the model against the test values NOAA
+// publishes with WMM2025. It is skipped when wmm.cof holds another model.
+func TestMagneticDeclination(t *testing.T) {
+	if m := wmm(); m.Name != "WMM-2025" {
+		t.Skipf("wmm.cof holds %s; the test values are for WMM-2025", m.Name)
+	}
+	y2025 := utc(2025, 1, 1, 0, 0)
+	y2027half := utc(2027, 7, 2, 12, 0) // 2027.5
+	tests := []struct {
+		when     time.Time
+		altKm    float64
+		lat, lon float64
+		want     float64
+	}{
+		{y2025, 0, 80, 0, 1.28},
+		{y2025, 0, 0, 120, -0.16},
+		{y2025, 0, -80, 240, 68.78},
+		{y2025, 100, 80, 0, 0.85},
+		{y2025, 100, 0, 120, -0.15},
+		{y2025, 100, -80, 240, 68.21},
+		{y2027half, 0, 80, 0, 2.60},
+		{y2027half, 0, 0, 120, -0.24},
+		{y2027half, 0, -80, 240, 68.49},
+		{y2027half, 100, 80, 0, 2.16},
+		{y2027half, 100, 0, 120, -0.23},
+		{y2027half, 100, -80, 240, 67.93},
+	}
+	for _, tt := range tests {
+		got := magneticDeclination(latLon{Lat: tt.lat, Lon: tt.lon}, tt.altKm, tt.when)
+		if math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("declination at %v,%v, %v km on %.1f = %.2f, want %.2f", tt.lat, tt.lon, tt.altKm, decimalYear(tt.when), got, tt.want)
+		}
+	}
+}
+
+func TestWMMCovers(t *testing.T) {
+	m := wmm()
+	if !m.covers(utc(2026, 10, 17, 12, 0)) {
+		t.Errorf("%s does not cover 2026; wmm.cof is out of date", m.Name)
+	}
+	start := int(m.Epoch)
+	tests := []struct {
+		when time.Time
+		want bool
+	}{
+		{utc(start-1, 12, 31, 23, 0), false},
+		{utc(start, 1, 1, 0, 0), true},
+		{utc(start+wmmSpan-1, 12, 31, 23, 0), true},
+		{utc(start+wmmSpan, 1, 1, 0, 0), false},
+	}
+	for _, tt := range tests {
+		if got := m.covers(tt.when); got != tt.want {
+			t.Errorf("%s covers %v = %v, want %v", m.Name, tt.when, got, tt.want)
+		}
+	}
+}
+
+func TestMagneticVariationOutsideModel(t *testing.T) {
+	m := wmm()
+	o := &Observation{
+		Info:       &stationInfo{Lat: 35.81, Lon: -83.99},
+		ObservedAt: utc(int(m.Epoch)+wmmSpan+1, 6, 1, 12, 0),
+	}
+	if v, ok := magneticVariation(o); ok {
+		t.Errorf("variation %.1f outside %s, want none", v, m.Name)
+	}
+	o.ObservedAt = utc(int(m.Epoch)+1, 6, 1, 12, 0)
+	if _, ok := magneticVariation(o); !ok {
+		t.Errorf("no variation inside %s", m.Name)
+	}
+}
+
+func TestDescribeVariation(t *testing.T) {
+	tests := []struct {
+		deg  float64
+		want string
+	}{
+		{-6.14, "6.1° W"},
+		{14.2, "14.2° E"},
+		{-0.04, "0.0° E"},
+	}
+	for _, tt := range tests {
+		if got := describeVariation(tt.deg); got != tt.want {
+			t.Errorf("describeVariation(%v) = %q, want %q", tt.deg, got, tt.want)
+		}
+	}
+}